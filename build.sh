@@ -19,10 +19,22 @@ echo "Building Agent..."
 go build -o bin/monitor-agent ./cmd/agent
 echo "✓ Agent built successfully"
 
+# Build terminal client
+echo "Building monitor-top..."
+go build -o bin/monitor-top ./cmd/monitor-top
+echo "✓ monitor-top built successfully"
+
+# Build command-line client
+echo "Building monitor-cli..."
+go build -o bin/monitor-cli ./cmd/monitor-cli
+echo "✓ monitor-cli built successfully"
+
 echo ""
 echo "Build complete! Binaries are in the bin/ directory:"
 echo "  - bin/monitor-server (API Server)"
 echo "  - bin/monitor-agent (Agent)"
+echo "  - bin/monitor-top (Terminal client)"
+echo "  - bin/monitor-cli (Command-line client)"
 echo ""
 echo "To run:"
 echo "  Server: ./bin/monitor-server -config ./configs/server-config.yaml"
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"log"
 	"os"
-	"runtime"
+	"strings"
 	"time"
 
 	"github.com/monitor-system/internal/agent/collector"
 	"github.com/monitor-system/internal/agent/config"
 	"github.com/monitor-system/internal/agent/reporter"
+	"github.com/monitor-system/internal/agent/scheduler"
+	"github.com/monitor-system/internal/agent/status"
 	"github.com/monitor-system/internal/server/model"
 )
 
+// version is set at build time via -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
 	configPath := flag.String("config", "./configs/agent-config.yaml", "Path to config file")
 	flag.Parse()
@@ -24,26 +30,53 @@ func main() {
 	}
 
 	// Initialize collector and reporter
-	col := collector.New()
-	rep := reporter.New(cfg.API.Endpoint, cfg.API.AgentKey)
+	col, err := collector.New(collector.Options{
+		Mountpoints:      cfg.Collectors.Disks.Mountpoints,
+		IgnoreFSTypes:    cfg.Collectors.Disks.IgnoreFSTypes,
+		InterfaceInclude: cfg.Collectors.Network.Include,
+		InterfaceExclude: cfg.Collectors.Network.Exclude,
+		SysfsRoot:        cfg.Collectors.Sensors.SysfsRoot,
+	})
+	if err != nil {
+		log.Fatalf("Invalid collector config: %v", err)
+	}
+	rep := reporter.New(cfg.API.Endpoint, cfg.API.AgentKey, cfg.Reporting.QueueSize)
+	tracker := status.New(version)
+	sched, err := newScheduler(cfg, col)
+	if err != nil {
+		log.Fatalf("Invalid collector config: %v", err)
+	}
+	sched.Observe(tracker.RecordCollection)
 
-	log.Printf("Starting agent for server: %s (%s)", cfg.Server.Name, cfg.Server.ID)
+	if cfg.Health.Enabled {
+		// 连续 3 个上报周期没有成功视为不健康
+		maxAge := 3 * seconds(cfg.Reporting.Interval)
+		go func() {
+			log.Printf("Health endpoint listening on %s", cfg.Health.Listen)
+			if err := status.Serve(cfg.Health.Listen, tracker, maxAge); err != nil {
+				log.Printf("Health endpoint stopped: %v", err)
+			}
+		}()
+	}
+
+	log.Printf("Starting agent %s for server: %s (%s)", version, cfg.Server.Name, cfg.Server.ID)
 	log.Printf("Reporting to: %s", cfg.API.Endpoint)
+	log.Printf("Enabled collectors: %s", strings.Join(sched.Names(), ", "))
 
-	// Get OS info
-	osInfo := runtime.GOOS
+	// 发行版及版本，与资产信息一致
+	osInfo := col.OSName()
 
 	ticker := time.NewTicker(time.Duration(cfg.Reporting.Interval) * time.Second)
 	defer ticker.Stop()
 
 	// Send initial report immediately
-	if err := sendReport(cfg, col, rep, osInfo); err != nil {
+	if err := sendReport(cfg, sched, rep, tracker, osInfo); err != nil {
 		log.Printf("Failed to send initial report: %v", err)
 	}
 
 	// Send periodic reports
 	for range ticker.C {
-		if err := sendReport(cfg, col, rep, osInfo); err != nil {
+		if err := sendReport(cfg, sched, rep, tracker, osInfo); err != nil {
 			log.Printf("Failed to send report: %v", err)
 		} else {
 			log.Printf("Report sent successfully")
@@ -51,38 +84,157 @@ func main() {
 	}
 }
 
-func sendReport(cfg *config.Config, col *collector.Collector, rep *reporter.Reporter, osInfo string) error {
-	// Collect all data
-	metrics, err := col.CollectMetrics()
-	if err != nil {
-		return err
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// newScheduler registers every enabled collector with its own interval.
+func newScheduler(cfg *config.Config, col *collector.Collector) (*scheduler.Scheduler, error) {
+	sched := scheduler.New(seconds(cfg.Reporting.FullInterval))
+	cc := cfg.Collectors
+
+	if cc.Metrics.IsEnabled() {
+		sched.Add(scheduler.Section{
+			Name:     "metrics",
+			Interval: seconds(cc.Metrics.Interval),
+			Always:   true,
+			Collect: func() (interface{}, error) {
+				metrics, err := col.CollectMetrics()
+				if err != nil {
+					return nil, err
+				}
+				metrics.ServerID = cfg.Server.ID
+				return metrics, nil
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Metrics = data.(*model.Metrics)
+			},
+		})
 	}
-	metrics.ServerID = cfg.Server.ID
 
-	info, err := col.CollectServerInfo()
-	if err != nil {
-		return err
+	if cc.Info.IsEnabled() {
+		sched.Add(scheduler.Section{
+			Name:     "info",
+			Interval: seconds(cc.Info.Interval),
+			Collect: func() (interface{}, error) {
+				info, err := col.CollectServerInfo()
+				if err != nil {
+					return nil, err
+				}
+				info.ServerID = cfg.Server.ID
+				return info, nil
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Info = data.(*model.ServerInfo)
+			},
+		})
 	}
-	info.ServerID = cfg.Server.ID
 
-	disks, err := col.CollectDisks()
-	if err != nil {
-		log.Printf("Failed to collect disks: %v", err)
-		disks = []model.Disk{}
+	if cc.Disks.IsEnabled() {
+		sched.Add(scheduler.Section{
+			Name:     "disks",
+			Interval: seconds(cc.Disks.Interval),
+			Collect: func() (interface{}, error) {
+				return col.CollectDisks()
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Disks = data.([]model.Disk)
+			},
+		})
 	}
 
-	processes, err := col.CollectProcesses(20)
-	if err != nil {
-		log.Printf("Failed to collect processes: %v", err)
-		processes = []model.Process{}
+	if cc.Processes.IsEnabled() {
+		sched.Add(scheduler.Section{
+			Name:     "processes",
+			Interval: seconds(cc.Processes.Interval),
+			Collect: func() (interface{}, error) {
+				return col.CollectProcesses(cc.Processes.Limit)
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Processes = data.([]model.Process)
+			},
+		})
 	}
 
-	network, err := col.CollectNetwork()
-	if err != nil {
-		log.Printf("Failed to collect network: %v", err)
-		network = []model.NetworkInterface{}
+	if cc.Network.IsEnabled() {
+		sched.Add(scheduler.Section{
+			Name:     "network",
+			Interval: seconds(cc.Network.Interval),
+			Collect: func() (interface{}, error) {
+				return col.CollectNetwork()
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Network = data.([]model.NetworkInterface)
+			},
+		})
+	}
+
+	if cc.Inventory.IsEnabled() {
+		sched.Add(scheduler.Section{
+			Name:     "inventory",
+			Interval: seconds(cc.Inventory.Interval),
+			Collect: func() (interface{}, error) {
+				return col.CollectInventory()
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Inventory = data.(*model.Inventory)
+			},
+		})
+	}
+
+	if cc.Sensors.IsEnabled() {
+		sched.Add(scheduler.Section{
+			Name:     "sensors",
+			Interval: seconds(cc.Sensors.Interval),
+			Always:   true, // 服务端保存历史，每次都发送
+			Collect: func() (interface{}, error) {
+				return col.CollectSensors()
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Sensors = data.([]model.Sensor)
+			},
+		})
+	}
+
+	if cc.Packages.IsEnabled() {
+		opts := collector.PackageOptions{
+			DpkgStatus:   cc.Packages.DpkgStatus,
+			RPM:          cc.Packages.UseRPM(),
+			FullInterval: seconds(cc.Packages.FullInterval),
+		}
+		for _, cmd := range cc.Packages.Commands {
+			opts.Commands = append(opts.Commands, collector.VersionCommand{
+				Name:    cmd.Name,
+				Command: cmd.Command,
+				Pattern: cmd.Pattern,
+			})
+		}
+
+		packages, err := collector.NewPackageCollector(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		sched.Add(scheduler.Section{
+			Name:     "packages",
+			Interval: seconds(cc.Packages.Interval),
+			Collect: func() (interface{}, error) {
+				return packages.Collect()
+			},
+			Apply: func(r *model.AgentReport, data interface{}) {
+				r.Packages = data.(*model.PackageReport)
+			},
+			Sent: func(data interface{}) {
+				packages.Acknowledge(data.(*model.PackageReport))
+			},
+			Reset: packages.Reset,
+		})
 	}
 
+	return sched, nil
+}
+
+func sendReport(cfg *config.Config, sched *scheduler.Scheduler, rep *reporter.Reporter, tracker *status.Tracker, osInfo string) error {
 	// Get hostname for server name if not set
 	serverName := cfg.Server.Name
 	if serverName == "" {
@@ -100,20 +252,38 @@ func sendReport(cfg *config.Config, col *collector.Collector, rep *reporter.Repo
 		location = "未知"
 	}
 
-	// Create report
+	// 始终发送标签（可能为空），以便服务端清除已删除的标签
+	labels := cfg.Server.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	// Create report; sections that are not due or unchanged are left empty
 	report := &model.AgentReport{
 		ServerID:   cfg.Server.ID,
 		ServerName: serverName, // 包含服务器名称
 		OS:         osInfo,     // 包含操作系统信息
 		Location:   location,   // 包含位置信息
+		Labels:     labels,
 		Timestamp:  time.Now(),
-		Metrics:    *metrics,
-		Info:       *info,
-		Disks:      disks,
-		Processes:  processes,
-		Network:    network,
 	}
+	batch := sched.Collect(report.Timestamp, report)
+	tracker.Sample()
+	report.Agent = tracker.Snapshot()
 
-	// Send report
-	return rep.Report(report)
+	// Send report; a queued report is retried by the reporter, so its
+	// sections are committed as if delivered. Sections of a report that was
+	// not queued stay due and are collected again next time.
+	err := rep.Report(report)
+	tracker.RecordReport(err, rep.QueueDepth())
+	if err != nil && !errors.Is(err, reporter.ErrQueued) {
+		return err
+	}
+
+	batch.Commit()
+	if rep.TakeResync() {
+		log.Printf("Earlier report data was lost, resending full state")
+		sched.Resync()
+	}
+	return err
 }
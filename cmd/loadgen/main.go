@@ -0,0 +1,288 @@
+// Command loadgen simulates many agents reporting to a monitor server, to
+// find out how many agents one server instance can handle.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type options struct {
+	server     string
+	agentKey   string
+	agents     int
+	interval   time.Duration
+	duration   time.Duration
+	ramp       time.Duration
+	jitter     float64
+	churn      float64
+	offline    time.Duration
+	processes  int
+	disks      int
+	interfaces int
+	prefix     string
+	timeout    time.Duration
+	progress   time.Duration
+	seed       int64
+}
+
+func main() {
+	opts := &options{}
+	flag.StringVar(&opts.server, "server", "http://localhost:8080", "Server endpoint")
+	flag.StringVar(&opts.agentKey, "agent-key", "your-secret-agent-key", "Agent key")
+	flag.IntVar(&opts.agents, "agents", 100, "Number of simulated agents")
+	flag.DurationVar(&opts.interval, "interval", 5*time.Second, "Report interval per agent")
+	flag.DurationVar(&opts.duration, "duration", time.Minute, "Test duration (0 = until interrupted)")
+	flag.DurationVar(&opts.ramp, "ramp", 0, "Spread agent start-up over this period (default: one interval)")
+	flag.Float64Var(&opts.jitter, "jitter", 0.1, "Random jitter applied to each interval, as a fraction")
+	flag.Float64Var(&opts.churn, "churn", 0, "Probability per report that an agent goes offline")
+	flag.DurationVar(&opts.offline, "offline", 90*time.Second, "How long a churned agent stays offline")
+	flag.IntVar(&opts.processes, "processes", 20, "Processes per report")
+	flag.IntVar(&opts.disks, "disks", 2, "Disks per report")
+	flag.IntVar(&opts.interfaces, "interfaces", 2, "Network interfaces per report")
+	flag.StringVar(&opts.prefix, "prefix", "loadgen", "Server ID prefix for simulated agents")
+	flag.DurationVar(&opts.timeout, "timeout", 10*time.Second, "HTTP request timeout")
+	flag.DurationVar(&opts.progress, "progress", 10*time.Second, "Progress output interval (0 = off)")
+	flag.Int64Var(&opts.seed, "seed", time.Now().UnixNano(), "Random seed")
+	flag.Parse()
+
+	if opts.agents <= 0 || opts.interval <= 0 {
+		log.Fatal("agents and interval must be positive")
+	}
+	if opts.ramp <= 0 {
+		opts.ramp = opts.interval
+	}
+	opts.server = strings.TrimRight(opts.server, "/")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if opts.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-quit
+		cancel()
+	}()
+
+	client := &http.Client{
+		Timeout: opts.timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        opts.agents,
+			MaxIdleConnsPerHost: opts.agents,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+
+	st := newStats()
+	log.Printf("Simulating %d agents against %s (interval %s, jitter %.0f%%, churn %.2f%%)",
+		opts.agents, opts.server, opts.interval, opts.jitter*100, opts.churn*100)
+
+	if opts.progress > 0 {
+		go st.printProgress(ctx, opts.progress)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.agents; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			runAgent(ctx, client, opts, newSimAgent(i, opts, opts.seed), st)
+		}(i)
+	}
+	wg.Wait()
+
+	st.printSummary(opts)
+}
+
+// runAgent reports on the configured interval until ctx is done.
+func runAgent(ctx context.Context, client *http.Client, opts *options, agent *simAgent, st *stats) {
+	// 在 ramp 时间内错开启动，避免所有 Agent 同时上报
+	delay := time.Duration(agent.rng.Int63n(int64(opts.ramp)))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if opts.churn > 0 && agent.rng.Float64() < opts.churn {
+			st.recordChurn()
+			delay = opts.offline
+			continue
+		}
+
+		send(ctx, client, opts, agent, st)
+
+		delay = opts.interval
+		if opts.jitter > 0 {
+			delay += time.Duration((agent.rng.Float64()*2 - 1) * opts.jitter * float64(opts.interval))
+		}
+	}
+}
+
+func send(ctx context.Context, client *http.Client, opts *options, agent *simAgent, st *stats) {
+	data, err := json.Marshal(agent.report())
+	if err != nil {
+		st.record(0, "marshal")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", opts.server+"/api/v1/agent/report", bytes.NewReader(data))
+	if err != nil {
+		st.record(0, "request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Key", opts.agentKey)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			return // 测试结束时被取消的请求不计入
+		}
+		st.record(elapsed, "network")
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		st.record(elapsed, fmt.Sprintf("HTTP %d", resp.StatusCode))
+		return
+	}
+	st.record(elapsed, "")
+}
+
+type stats struct {
+	mu        sync.Mutex
+	start     time.Time
+	latencies []time.Duration
+	ok        int
+	failed    int
+	errors    map[string]int
+	churned   int
+}
+
+func newStats() *stats {
+	return &stats{start: time.Now(), errors: make(map[string]int)}
+}
+
+// record stores the result of one request; kind is empty on success.
+func (s *stats) record(latency time.Duration, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kind != "" {
+		s.failed++
+		s.errors[kind]++
+		return
+	}
+	s.ok++
+	s.latencies = append(s.latencies, latency)
+}
+
+func (s *stats) recordChurn() {
+	s.mu.Lock()
+	s.churned++
+	s.mu.Unlock()
+}
+
+func (s *stats) printProgress(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	lastOK, lastFailed := 0, 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		ok, failed := s.ok, s.failed
+		s.mu.Unlock()
+
+		rate := float64(ok-lastOK) / every.Seconds()
+		log.Printf("%6.0fs  ok=%d failed=%d  %.1f req/s (last %s, %d errors)",
+			time.Since(s.start).Seconds(), ok, failed, rate, every, failed-lastFailed)
+		lastOK, lastFailed = ok, failed
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p/100*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func (s *stats) printSummary(opts *options) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.start)
+	total := s.ok + s.failed
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+
+	errorRate := 0.0
+	if total > 0 {
+		errorRate = float64(s.failed) / float64(total) * 100
+	}
+
+	fmt.Println()
+	fmt.Println("=== Load test summary ===")
+	fmt.Printf("Agents:       %d (interval %s)\n", opts.agents, opts.interval)
+	fmt.Printf("Duration:     %s\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("Requests:     %d (ok %d, failed %d)\n", total, s.ok, s.failed)
+	fmt.Printf("Throughput:   %.1f req/s (expected %.1f req/s)\n",
+		float64(s.ok)/elapsed.Seconds(), float64(opts.agents)/opts.interval.Seconds())
+	fmt.Printf("Error rate:   %.2f%%\n", errorRate)
+	fmt.Printf("Churned:      %d offline periods\n", s.churned)
+
+	if len(s.latencies) > 0 {
+		fmt.Println("Latency:")
+		for _, p := range []float64{50, 90, 95, 99} {
+			fmt.Printf("  p%-3.0f        %s\n", p, percentile(s.latencies, p).Round(time.Microsecond))
+		}
+		fmt.Printf("  max         %s\n", s.latencies[len(s.latencies)-1].Round(time.Microsecond))
+	}
+
+	if len(s.errors) > 0 {
+		fmt.Println("Errors:")
+		kinds := make([]string, 0, len(s.errors))
+		for kind := range s.errors {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Printf("  %-12s%d\n", kind, s.errors[kind])
+		}
+	}
+}
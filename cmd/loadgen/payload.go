@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+var processNames = []string{
+	"systemd", "sshd", "nginx", "postgres", "redis-server", "java", "python3",
+	"node", "dockerd", "containerd", "kubelet", "cron", "rsyslogd", "bash",
+}
+
+// simAgent produces synthetic reports for one fake server. Values drift
+// around a per-agent baseline so the data looks like a real host.
+type simAgent struct {
+	id       string
+	rng      *rand.Rand
+	cfg      *options
+	cpuBase  float64
+	memBase  float64
+	memTotal int64
+	cores    int
+	phase    float64
+	labels   map[string]string
+	started  time.Time
+	sent     int
+	netTotal uint64
+}
+
+func newSimAgent(i int, cfg *options, seed int64) *simAgent {
+	rng := rand.New(rand.NewSource(seed + int64(i)))
+	return &simAgent{
+		id:       fmt.Sprintf("%s-%05d", cfg.prefix, i),
+		rng:      rng,
+		cfg:      cfg,
+		cpuBase:  5 + rng.Float64()*50,
+		memBase:  20 + rng.Float64()*60,
+		memTotal: int64(4+rng.Intn(60)) << 30,
+		cores:    []int{2, 4, 8, 16, 32}[rng.Intn(5)],
+		phase:    rng.Float64() * 2 * math.Pi,
+		labels: map[string]string{
+			"env":     []string{"prod", "prod", "staging"}[i%3],
+			"role":    []string{"web", "db", "cache", "worker"}[i%4],
+			"loadgen": "true",
+		},
+		started: time.Now().Add(-time.Duration(rng.Intn(90*24)) * time.Hour),
+	}
+}
+
+func (a *simAgent) wave(base, amplitude float64) float64 {
+	v := base + amplitude*math.Sin(float64(time.Now().Unix())/300+a.phase) + a.rng.NormFloat64()*amplitude/3
+	return math.Max(0, math.Min(100, v))
+}
+
+// report builds the next payload. Like the real agent, slower sections are
+// only included every few reports.
+func (a *simAgent) report() *model.AgentReport {
+	now := time.Now()
+	netIn := a.rng.Float64() * 10
+	netOut := a.rng.Float64() * 5
+	a.netTotal += uint64((netIn + netOut) * float64(a.cfg.interval/time.Second) * 1024 * 1024)
+
+	report := &model.AgentReport{
+		ServerID:   a.id,
+		ServerName: a.id,
+		OS:         "Linux (loadgen)",
+		Location:   "loadgen",
+		Labels:     a.labels,
+		Timestamp:  now,
+		Metrics: &model.Metrics{
+			Timestamp:  now,
+			CPU:        a.wave(a.cpuBase, 15),
+			Memory:     a.wave(a.memBase, 5),
+			DiskRead:   a.rng.Float64() * 20,
+			DiskWrite:  a.rng.Float64() * 10,
+			NetworkIn:  netIn,
+			NetworkOut: netOut,
+		},
+	}
+
+	if a.cfg.interfaces > 0 {
+		for i := 0; i < a.cfg.interfaces; i++ {
+			report.Network = append(report.Network, model.NetworkInterface{
+				Name:          fmt.Sprintf("eth%d", i),
+				Type:          "ethernet",
+				UploadSpeed:   netOut / float64(a.cfg.interfaces),
+				DownloadSpeed: netIn / float64(a.cfg.interfaces),
+				TotalUpload:   a.netTotal / 3,
+				TotalDownload: a.netTotal * 2 / 3,
+				Status:        "up",
+			})
+		}
+	}
+
+	if a.sent%6 == 0 {
+		for i := 0; i < a.cfg.processes; i++ {
+			report.Processes = append(report.Processes, model.Process{
+				PID:    int32(1000 + i),
+				Name:   processNames[a.rng.Intn(len(processNames))],
+				CPU:    a.rng.Float64() * a.cpuBase / 2,
+				Memory: a.rng.Float64() * 5,
+				User:   "root",
+				Status: "running",
+			})
+		}
+	}
+
+	if a.sent%12 == 0 {
+		used := int64(float64(a.memTotal) * report.Metrics.Memory / 100)
+		report.Info = &model.ServerInfo{
+			CPUCores:    a.cores,
+			TotalMemory: a.memTotal,
+			UsedMemory:  used,
+			Uptime:      int64(now.Sub(a.started).Seconds()),
+		}
+		for i := 0; i < a.cfg.disks; i++ {
+			total := uint64(100+a.rng.Intn(900)) << 30
+			usage := 10 + a.rng.Float64()*80
+			usedDisk := uint64(float64(total) * usage / 100)
+			report.Disks = append(report.Disks, model.Disk{
+				Name:          fmt.Sprintf("/dev/sd%c1", 'a'+i),
+				MountPoint:    []string{"/", "/data", "/var", "/home", "/backup"}[i%5],
+				FSType:        "ext4",
+				TotalSize:     total,
+				UsedSize:      usedDisk,
+				AvailableSize: total - usedDisk,
+				UsagePercent:  usage,
+			})
+		}
+	}
+
+	a.sent++
+	return report
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/monitor-system/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the optional config file, by default
+// ~/.config/monitor/cli.yaml.
+type fileConfig struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	Format string `yaml:"format"`
+}
+
+// globals holds the flags shared by every command. Flags win over
+// environment variables, which win over the config file.
+type globals struct {
+	url    string
+	apiKey string
+	config string
+	format string
+}
+
+func (g *globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.url, "url", g.url, "Server endpoint (env MONITOR_SERVER)")
+	fs.StringVar(&g.apiKey, "api-key", g.apiKey, "API key (env MONITOR_API_KEY)")
+	fs.StringVar(&g.config, "config", g.config, "Config file (env MONITOR_CONFIG)")
+	fs.StringVar(&g.format, "format", g.format, "Output format: table, json, yaml or csv")
+	fs.StringVar(&g.format, "o", g.format, "Shorthand for -format")
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "monitor", "cli.yaml")
+}
+
+// resolve fills unset globals from the environment, the config file and
+// defaults, in that order.
+func (g *globals) resolve() error {
+	path, explicit := g.config, g.config != ""
+	if !explicit {
+		path, explicit = os.Getenv("MONITOR_CONFIG"), os.Getenv("MONITOR_CONFIG") != ""
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	var file fileConfig
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &file); err != nil {
+				return fmt.Errorf("parse %s: %w", path, err)
+			}
+		// 默认配置文件可以不存在
+		case explicit || !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+	}
+
+	pick := func(flagValue, env, fileValue, def string) string {
+		for _, v := range []string{flagValue, os.Getenv(env), fileValue} {
+			if v != "" {
+				return v
+			}
+		}
+		return def
+	}
+	g.url = pick(g.url, "MONITOR_SERVER", file.URL, "http://localhost:8080")
+	g.apiKey = pick(g.apiKey, "MONITOR_API_KEY", file.APIKey, "")
+	g.format = pick(g.format, "MONITOR_FORMAT", file.Format, formatTable)
+
+	switch g.format {
+	case formatTable, formatJSON, formatYAML, formatCSV:
+	default:
+		return usageErr("unknown format %q, use table, json, yaml or csv", g.format)
+	}
+	if g.apiKey == "" {
+		return fmt.Errorf("API key is required, use --api-key, MONITOR_API_KEY or api_key in %s", path)
+	}
+	return nil
+}
+
+func (g *globals) client() (*client.Client, error) {
+	if err := g.resolve(); err != nil {
+		return nil, err
+	}
+	return client.New(g.url, g.apiKey), nil
+}
+
+// newFlagSet returns a flag set for a subcommand that also accepts the
+// global flags.
+func newFlagSet(g *globals, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	g.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: monitor-cli %s %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,127 @@
+// Command monitor-cli is a scriptable client for the monitor server API:
+// list, inspect and delete servers and read their history, processes, disks
+// and network interfaces as a table, JSON, YAML or CSV.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+const usage = `Usage: monitor-cli [global flags] <command> [flags]
+
+Commands:
+  servers list              List servers
+  servers get <id>          Show one server
+  servers delete <id>       Delete a server and its data
+  history --server <id>     Metrics history (--since 1h, --start/--end, --step)
+  processes --server <id>   Top processes
+  disks --server <id>       Disk usage
+  network --server <id>     Network interfaces
+
+Global flags (also accepted after the command):
+  --url URL         Server endpoint (env MONITOR_SERVER)
+  --api-key KEY     API key (env MONITOR_API_KEY)
+  --config FILE     Config file (env MONITOR_CONFIG, default %s)
+  -o, --format FMT  Output format: table, json, yaml or csv
+
+Run "monitor-cli <command> -h" for the flags of a command.
+`
+
+type command struct {
+	name string
+	run  func(ctx context.Context, g *globals, args []string) error
+}
+
+var commands = []command{
+	{"servers", runServers},
+	{"history", runHistory},
+	{"processes", runProcesses},
+	{"disks", runDisks},
+	{"network", runNetwork},
+}
+
+func main() {
+	g := &globals{}
+	fs := flag.NewFlagSet("monitor-cli", flag.ContinueOnError)
+	g.register(fs)
+	fs.Usage = func() { fmt.Fprintf(os.Stderr, usage, defaultConfigPath()) }
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			err = errBadFlags
+		}
+		exit(err)
+	}
+
+	args := fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			exit(cmd.run(ctx, g, args[1:]))
+		}
+	}
+	if args[0] == "help" {
+		fs.Usage()
+		return
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+	fs.Usage()
+	os.Exit(2)
+}
+
+func exit(err error) {
+	var usage usageError
+	switch {
+	case err == nil, err == flag.ErrHelp:
+		os.Exit(0)
+	case err == errBadFlags:
+		// flag 包已经输出了错误和用法
+		os.Exit(2)
+	case errors.As(err, &usage):
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+var errBadFlags = errors.New("invalid flags")
+
+// usageError is a mistake on the command line rather than a failed request.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usageErr(format string, args ...interface{}) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+// parseArgs parses flags placed before, between or after positional
+// arguments and returns the positional ones.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, errBadFlags
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/pkg/client"
+)
+
+// serverFlag adds the --server flag required by the per-server commands.
+func serverFlag(fs *flag.FlagSet) *string {
+	return fs.String("server", "", "Server ID (required)")
+}
+
+func requireServer(name, id string) error {
+	if id == "" {
+		return usageErr("usage: monitor-cli %s --server <id>", name)
+	}
+	return nil
+}
+
+func runHistory(ctx context.Context, g *globals, args []string) error {
+	fs := newFlagSet(g, "history", "--server <id> [flags]")
+	server := serverFlag(fs)
+	since := fs.String("since", "1h", "Time range ending now or at --end, e.g. 30m, 6h, 7d")
+	start := fs.String("start", "", "Range start (RFC 3339), overrides --since")
+	end := fs.String("end", "", "Range end (RFC 3339, default now)")
+	step := fs.Duration("step", 0, "Aggregate into buckets of this size, e.g. 5m (default raw or server chosen)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := requireServer("history", *server); err != nil {
+		return err
+	}
+
+	r := client.Range{Step: *step}
+	var err error
+	if r.Duration, err = parseSince(*since); err != nil {
+		return usageErr("invalid --since %q", *since)
+	}
+	if *start != "" {
+		if r.Start, err = time.Parse(time.RFC3339, *start); err != nil {
+			return usageErr("invalid --start %q, use RFC 3339 like 2024-01-02T15:04:05Z", *start)
+		}
+	}
+	if *end != "" {
+		if r.End, err = time.Parse(time.RFC3339, *end); err != nil {
+			return usageErr("invalid --end %q, use RFC 3339 like 2024-01-02T15:04:05Z", *end)
+		}
+	}
+
+	c, err := g.client()
+	if err != nil {
+		return err
+	}
+	h, err := c.History(ctx, *server, r)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"timestamp", "cpu", "memory", "disk_read", "disk_write", "network_in", "network_out"}}
+	for _, m := range h.History {
+		t.add(fmtTime(m.Timestamp), fmtFloat(m.CPU), fmtFloat(m.Memory), fmtFloat(m.DiskRead),
+			fmtFloat(m.DiskWrite), fmtFloat(m.NetworkIn), fmtFloat(m.NetworkOut))
+	}
+	return output(g.format, h, t)
+}
+
+// parseSince accepts a Go duration or a number of days such as "7d".
+func parseSince(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, strconv.ErrSyntax
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d <= 0 {
+		err = strconv.ErrRange
+	}
+	return d, err
+}
+
+func runProcesses(ctx context.Context, g *globals, args []string) error {
+	fs := newFlagSet(g, "processes", "--server <id> [flags]")
+	server := serverFlag(fs)
+	sortBy := fs.String("sort", "cpu", "Sort by cpu or memory")
+	limit := fs.Int("limit", 20, "Number of processes")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := requireServer("processes", *server); err != nil {
+		return err
+	}
+
+	c, err := g.client()
+	if err != nil {
+		return err
+	}
+	procs, err := c.Processes(ctx, *server, *sortBy, *limit)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"pid", "name", "user", "status", "cpu", "memory"}}
+	for _, p := range procs {
+		t.add(strconv.Itoa(int(p.PID)), p.Name, p.User, p.Status, fmtFloat(p.CPU), fmtFloat(p.Memory))
+	}
+	return output(g.format, procs, t)
+}
+
+func runDisks(ctx context.Context, g *globals, args []string) error {
+	fs := newFlagSet(g, "disks", "--server <id> [flags]")
+	server := serverFlag(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := requireServer("disks", *server); err != nil {
+		return err
+	}
+
+	c, err := g.client()
+	if err != nil {
+		return err
+	}
+	disks, err := c.Disks(ctx, *server)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"name", "mount_point", "fs_type", "total_mb", "used_mb", "available_mb", "usage"}}
+	for _, d := range disks {
+		t.add(d.Name, d.MountPoint, d.FSType, strconv.FormatUint(d.TotalSize, 10),
+			strconv.FormatUint(d.UsedSize, 10), strconv.FormatUint(d.AvailableSize, 10), fmtFloat(d.UsagePercent))
+	}
+	return output(g.format, disks, t)
+}
+
+func runNetwork(ctx context.Context, g *globals, args []string) error {
+	fs := newFlagSet(g, "network", "--server <id> [flags]")
+	server := serverFlag(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := requireServer("network", *server); err != nil {
+		return err
+	}
+
+	c, err := g.client()
+	if err != nil {
+		return err
+	}
+	ifaces, err := c.NetworkInterfaces(ctx, *server)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"name", "type", "status", "upload_mb_s", "download_mb_s", "total_upload_mb", "total_download_mb"}}
+	for _, n := range ifaces {
+		t.add(n.Name, n.Type, n.Status, fmtFloat(n.UploadSpeed), fmtFloat(n.DownloadSpeed),
+			strconv.FormatUint(n.TotalUpload, 10), strconv.FormatUint(n.TotalDownload, 10))
+	}
+	return output(g.format, ifaces, t)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/pkg/client"
+)
+
+func runServers(ctx context.Context, g *globals, args []string) error {
+	if len(args) == 0 {
+		return usageErr("usage: monitor-cli servers list|get|delete")
+	}
+	switch args[0] {
+	case "list":
+		return serversList(ctx, g, args[1:])
+	case "get":
+		return serversGet(ctx, g, args[1:])
+	case "delete":
+		return serversDelete(ctx, g, args[1:])
+	}
+	return usageErr("unknown servers command %q, use list, get or delete", args[0])
+}
+
+func serversList(ctx context.Context, g *globals, args []string) error {
+	var opts client.ListServersOptions
+	fs := newFlagSet(g, "servers list", "[flags]")
+	fs.StringVar(&opts.Search, "search", "", "Match name, IP or ID")
+	fs.StringVar(&opts.Status, "status", "", "online, offline, warning or maintenance")
+	fs.StringVar(&opts.Lifecycle, "lifecycle", "", "active, archived or all (default active)")
+	fs.StringVar(&opts.Sort, "sort", "", "Sort field, e.g. name, status, cpu, memory, last_heartbeat")
+	fs.BoolVar(&opts.Desc, "desc", false, "Sort descending")
+	fs.IntVar(&opts.Limit, "limit", 0, "Maximum number of servers (0 = all)")
+	fs.IntVar(&opts.Offset, "offset", 0, "Number of servers to skip")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	c, err := g.client()
+	if err != nil {
+		return err
+	}
+	list, err := c.ListServers(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"id", "name", "status", "ip", "os", "location", "cpu", "memory", "last_heartbeat"}}
+	for _, s := range list.Servers {
+		cpu, mem := "", ""
+		if s.CurrentMetrics != nil {
+			cpu, mem = fmtFloat(s.CurrentMetrics.CPU), fmtFloat(s.CurrentMetrics.Memory)
+		}
+		t.add(s.ID, s.Name, s.Status, s.IP, s.OS, s.Location, cpu, mem, fmtTime(s.LastHeartbeat))
+	}
+	return output(g.format, list, t)
+}
+
+func serversGet(ctx context.Context, g *globals, args []string) error {
+	fs := newFlagSet(g, "servers get", "<id> [flags]")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usageErr("usage: monitor-cli servers get <id>")
+	}
+
+	c, err := g.client()
+	if err != nil {
+		return err
+	}
+	s, err := c.GetServer(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"field", "value"}}
+	t.add("id", s.ID)
+	t.add("name", s.Name)
+	t.add("status", s.Status)
+	t.add("ip", s.IP)
+	t.add("os", s.OS)
+	t.add("location", s.Location)
+	if m := s.Metrics; m != nil {
+		t.add("cpu", fmtFloat(m.CPU))
+		t.add("memory", fmtFloat(m.Memory))
+		t.add("disk_read", fmtFloat(m.DiskRead))
+		t.add("disk_write", fmtFloat(m.DiskWrite))
+		t.add("network_in", fmtFloat(m.NetworkIn))
+		t.add("network_out", fmtFloat(m.NetworkOut))
+	}
+	if info := s.Info; info != nil {
+		t.add("cpu_cores", strconv.Itoa(info.CPUCores))
+		t.add("total_memory_mb", strconv.FormatInt(info.TotalMemory, 10))
+		t.add("used_memory_mb", strconv.FormatInt(info.UsedMemory, 10))
+		t.add("uptime", (time.Duration(info.Uptime) * time.Second).String())
+	}
+	if inv := s.Inventory; inv != nil {
+		t.add("hostname", inv.Hostname)
+		t.add("platform", strings.TrimSpace(inv.Platform+" "+inv.PlatformVersion))
+		t.add("kernel", strings.TrimSpace(inv.KernelVersion+" "+inv.KernelArch))
+		t.add("cpu_model", inv.CPUModel)
+	}
+	return output(g.format, s, t)
+}
+
+func serversDelete(ctx context.Context, g *globals, args []string) error {
+	fs := newFlagSet(g, "servers delete", "<id> [flags]")
+	noBlock := fs.Bool("no-block", false, "Let the agent register the server again")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usageErr("usage: monitor-cli servers delete <id>")
+	}
+	id := pos[0]
+
+	c, err := g.client()
+	if err != nil {
+		return err
+	}
+	if !*yes {
+		fmt.Fprintf(os.Stderr, "Delete server %s and all its data? [y/N] ", id)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("aborted")
+		}
+	}
+	if err := c.DeleteServer(ctx, id, !*noBlock); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Deleted server %s\n", id)
+	return nil
+}
+
+func fmtTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.RFC3339)
+}
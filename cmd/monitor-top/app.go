@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/monitor-system/pkg/api"
+	"github.com/monitor-system/pkg/client"
+)
+
+type view int
+
+const (
+	listView view = iota
+	detailView
+)
+
+var sortModes = []struct {
+	field string
+	desc  bool
+}{
+	{"name", false},
+	{"cpu", true},
+	{"memory", true},
+	{"status", false},
+}
+
+type detailData struct {
+	server    *api.ServerDetail
+	samples   []api.Metrics
+	processes []api.Process
+	disks     []api.Disk
+	ifaces    []api.NetworkInterface
+	end       time.Time
+	step      time.Duration
+	slots     int
+}
+
+type app struct {
+	c    *client.Client
+	opts *options
+
+	view     view
+	servers  []api.ServerListItem
+	selected int
+	offset   int
+	sortMode int
+	current  string // 详情页的服务器 ID
+	detail   *detailData
+
+	err     error
+	updated time.Time
+	// gen changes on every navigation; fetches started for an older view are
+	// discarded when they complete.
+	gen     int
+	loading bool // 当前视图有请求未返回时不再发起新请求
+	results chan result
+
+	width, height int
+}
+
+type result struct {
+	gen   int
+	apply func(a *app)
+}
+
+func newApp(c *client.Client, opts *options) *app {
+	a := &app{c: c, opts: opts, results: make(chan result, 4)}
+	a.width, a.height = terminalSize()
+	return a
+}
+
+func (a *app) run() {
+	keys := make(chan string, 16)
+	go readKeys(keys)
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(a.opts.interval)
+	defer ticker.Stop()
+
+	a.fetch()
+	a.draw()
+	for {
+		select {
+		case k := <-keys:
+			if !a.handleKey(k) {
+				return
+			}
+		case r := <-a.results:
+			if r.gen == a.gen {
+				r.apply(a)
+			}
+		case <-ticker.C:
+			a.fetch()
+		case <-resize:
+			a.width, a.height = terminalSize()
+			// 详情页的迷你图宽度随终端变化，需要重新取数
+			if a.view == detailView {
+				a.fetch()
+			}
+		case <-quit:
+			return
+		}
+		a.draw()
+	}
+}
+
+// handleKey applies a key press and reports whether to keep running.
+func (a *app) handleKey(k string) bool {
+	switch k {
+	case "quit":
+		return false
+	case "refresh":
+		a.fetch()
+	case "back":
+		if a.view == detailView {
+			a.view = listView
+			a.detail = nil
+			a.navigate()
+		}
+	}
+
+	if a.view != listView {
+		return true
+	}
+	page := max(1, a.listRows())
+	switch k {
+	case "up":
+		a.selected--
+	case "down":
+		a.selected++
+	case "pgup":
+		a.selected -= page
+	case "pgdn":
+		a.selected += page
+	case "home":
+		a.selected = 0
+	case "end":
+		a.selected = len(a.servers) - 1
+	case "sort":
+		a.sortMode = (a.sortMode + 1) % len(sortModes)
+		a.navigate()
+	case "enter":
+		if a.selected < len(a.servers) {
+			a.current = a.servers[a.selected].ID
+			a.view = detailView
+			a.navigate()
+		}
+	}
+	a.selected = max(0, min(a.selected, len(a.servers)-1))
+	return true
+}
+
+func (a *app) navigate() {
+	a.gen++
+	a.err = nil
+	a.loading = false
+	a.fetch()
+}
+
+// fetch loads the data for the current view in the background.
+func (a *app) fetch() {
+	if a.loading {
+		return
+	}
+	a.loading = true
+	gen := a.gen
+	if a.view == listView {
+		mode := sortModes[a.sortMode]
+		go func() {
+			list, err := a.c.ListServers(context.Background(), client.ListServersOptions{Sort: mode.field, Desc: mode.desc})
+			a.results <- result{gen: gen, apply: func(a *app) {
+				a.loading = false
+				a.err = err
+				if err == nil {
+					a.servers = list.Servers
+					a.updated = time.Now()
+					a.selected = max(0, min(a.selected, len(a.servers)-1))
+				}
+			}}
+		}()
+		return
+	}
+
+	id := a.current
+	slots := max(10, a.width-20)
+	step := (a.opts.span / time.Duration(slots)).Round(time.Second)
+	step = max(step, time.Second)
+	go func() {
+		d := &detailData{end: time.Now(), step: step, slots: slots}
+		var errs []string
+		check := func(err error) {
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		ctx := context.Background()
+		var err error
+		d.server, err = a.c.GetServer(ctx, id)
+		check(err)
+		history, err := a.c.History(ctx, id, client.Range{Duration: time.Duration(slots) * step, Step: step})
+		check(err)
+		if history != nil {
+			d.samples = history.History
+		}
+		d.processes, err = a.c.Processes(ctx, id, "cpu", 10)
+		check(err)
+		d.disks, err = a.c.Disks(ctx, id)
+		check(err)
+		d.ifaces, err = a.c.NetworkInterfaces(ctx, id)
+		check(err)
+
+		a.results <- result{gen: gen, apply: func(a *app) {
+			a.loading = false
+			a.err = nil
+			if len(errs) > 0 {
+				a.err = fmt.Errorf("%s", strings.Join(errs, "; "))
+			}
+			if d.server != nil && d.server.ID != "" {
+				a.detail = d
+				a.updated = time.Now()
+			}
+		}}
+	}()
+}
+
+func (a *app) draw() {
+	s := &screen{width: a.width, height: a.height}
+	if a.view == detailView {
+		a.drawDetail(s)
+	} else {
+		a.drawList(s)
+	}
+	os.Stdout.WriteString(s.String())
+}
+
+func (a *app) header(s *screen, title string) {
+	status := dim + "updated " + a.updated.Format("15:04:05") + fmt.Sprintf(" · every %s", a.opts.interval) + reset
+	if a.updated.IsZero() {
+		status = dim + "loading..." + reset
+	}
+	s.add("%smonitor-top%s  %s  %s  %s", bold, reset, a.opts.server, title, status)
+	if a.err != nil {
+		s.add("%serror: %v%s", red, a.err, reset)
+	} else {
+		s.blank()
+	}
+}
+
+func (a *app) footer(s *screen, help string) {
+	for len(s.lines) < a.height-1 {
+		s.blank()
+	}
+	s.lines = s.lines[:a.height-1]
+	s.add("%s%s%s", dim, help, reset)
+}
+
+// listRows is how many servers fit on screen.
+func (a *app) listRows() int {
+	return a.height - 4
+}
+
+func (a *app) drawList(s *screen) {
+	counts := map[string]int{}
+	for _, srv := range a.servers {
+		counts[srv.Status]++
+	}
+	summary := fmt.Sprintf("%d servers", len(a.servers))
+	for _, st := range []string{"online", "warning", "offline", "maintenance"} {
+		if counts[st] > 0 {
+			summary += fmt.Sprintf("  %s%d %s%s", statusColor(st), counts[st], st, reset)
+		}
+	}
+	a.header(s, summary)
+
+	nameWidth := max(12, a.width-78)
+	s.add("%s%s %-11s %-15s %6s %6s %10s %10s %12s%s", bold, pad("NAME", nameWidth),
+		"STATUS", "IP", "CPU%", "MEM%", "UP MB/s", "DOWN MB/s", "LAST SEEN", reset)
+
+	rows := max(1, a.listRows())
+	if a.selected < a.offset {
+		a.offset = a.selected
+	}
+	if a.selected >= a.offset+rows {
+		a.offset = a.selected - rows + 1
+	}
+	a.offset = max(0, min(a.offset, len(a.servers)-rows))
+
+	for i := a.offset; i < len(a.servers) && i < a.offset+rows; i++ {
+		srv := a.servers[i]
+		cpu, mem, up, down := "-", "-", "-", "-"
+		var cpuColor, memColor string
+		if m := srv.CurrentMetrics; m != nil {
+			cpu, mem = fmt.Sprintf("%.1f", m.CPU), fmt.Sprintf("%.1f", m.Memory)
+			up, down = fmt.Sprintf("%.2f", m.Upload), fmt.Sprintf("%.2f", m.Download)
+			cpuColor, memColor = percentColor(m.CPU), percentColor(m.Memory)
+		}
+
+		if i == a.selected {
+			// 选中行整体反色，不再单独着色
+			s.add("%s%s %-11s %-15s %6s %6s %10s %10s %12s%s", inverse, pad(srv.Name, nameWidth),
+				srv.Status, pad(srv.IP, 15), cpu, mem, up, down, ago(srv.LastHeartbeat), reset)
+			continue
+		}
+		s.add("%s %s%-11s%s %-15s %s%6s%s %s%6s%s %10s %10s %12s", pad(srv.Name, nameWidth),
+			statusColor(srv.Status), srv.Status, reset, pad(srv.IP, 15),
+			cpuColor, cpu, reset, memColor, mem, reset, up, down, ago(srv.LastHeartbeat))
+	}
+	if len(a.servers) == 0 && !a.updated.IsZero() {
+		s.add("%sno servers%s", dim, reset)
+	}
+
+	a.footer(s, fmt.Sprintf("↑/↓ select  enter details  s sort (%s)  r refresh  q quit", sortModes[a.sortMode].field))
+}
+
+func (a *app) drawDetail(s *screen) {
+	d := a.detail
+	if d == nil {
+		a.header(s, a.current)
+		a.footer(s, "esc back  r refresh  q quit")
+		return
+	}
+	srv := d.server
+	a.header(s, srv.Name)
+
+	line := fmt.Sprintf("%s%s%s (%s)  %s%s%s  %s  %s", bold, srv.Name, reset, srv.ID,
+		statusColor(srv.Status), srv.Status, reset, srv.IP, srv.OS)
+	if info := srv.Info; info != nil {
+		line += fmt.Sprintf("  %d cores  %s RAM  up %s", info.CPUCores, size(uint64(info.TotalMemory)), uptime(info.Uptime))
+	}
+	s.add("%s", line)
+	s.blank()
+
+	charts := []struct {
+		label string
+		unit  string
+		top   float64
+		value func(x *api.Metrics) float64
+	}{
+		{"CPU", "%", 100, func(x *api.Metrics) float64 { return x.CPU }},
+		{"MEM", "%", 100, func(x *api.Metrics) float64 { return x.Memory }},
+		{"NET OUT", " MB/s", 0, func(x *api.Metrics) float64 { return x.NetworkOut }},
+		{"NET IN", " MB/s", 0, func(x *api.Metrics) float64 { return x.NetworkIn }},
+		{"DISK R", " MB/s", 0, func(x *api.Metrics) float64 { return x.DiskRead }},
+		{"DISK W", " MB/s", 0, func(x *api.Metrics) float64 { return x.DiskWrite }},
+	}
+	for _, ch := range charts {
+		values := series(d.samples, ch.value, d.end, d.step, d.slots)
+		current := "-"
+		if v := last(values); !math.IsNaN(v) {
+			current = fmt.Sprintf("%.1f%s", v, ch.unit)
+		}
+		color := cyan
+		if ch.unit == "%" {
+			if c := percentColor(last(values)); c != "" {
+				color = c
+			}
+		}
+		s.add("%-7s %11s %s%s%s", ch.label, current, color, sparkline(values, ch.top), reset)
+	}
+	s.add("%slast %s, %s per cell%s", dim, time.Duration(d.slots)*d.step, d.step, reset)
+	s.blank()
+
+	s.add("%s%7s %-24s %-10s %-8s %6s %6s%s", bold, "PID", "PROCESS", "USER", "STATUS", "CPU%", "MEM%", reset)
+	for _, p := range d.processes {
+		s.add("%7d %s %s %s %s%6.1f%s %6.1f", p.PID, pad(p.Name, 24), pad(p.User, 10), pad(p.Status, 8),
+			percentColor(p.CPU), p.CPU, reset, p.Memory)
+	}
+	s.blank()
+
+	s.add("%s%-24s %-16s %-8s %8s %8s  %-22s%s", bold, "MOUNT", "DEVICE", "FS", "SIZE", "AVAIL", "USE", reset)
+	for _, dk := range d.disks {
+		used := int(math.Round(dk.UsagePercent / 100 * 16))
+		used = max(0, min(16, used))
+		bar := strings.Repeat("█", used) + strings.Repeat("░", 16-used)
+		s.add("%s %s %s %8s %8s  %s%s%s %5.1f%%", pad(dk.MountPoint, 24), pad(dk.Name, 16), pad(dk.FSType, 8),
+			size(dk.TotalSize), size(dk.AvailableSize), percentColor(dk.UsagePercent), bar, reset, dk.UsagePercent)
+	}
+	s.blank()
+
+	s.add("%s%-16s %-8s %12s %12s%s", bold, "INTERFACE", "STATUS", "UP MB/s", "DOWN MB/s", reset)
+	for _, n := range d.ifaces {
+		s.add("%s %s %12.2f %12.2f", pad(n.Name, 16), pad(n.Status, 8), n.UploadSpeed, n.DownloadSpeed)
+	}
+
+	a.footer(s, "esc back  r refresh  q quit")
+}
+
+// readKeys turns raw terminal input into key names.
+func readKeys(keys chan<- string) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			keys <- "quit"
+			return
+		}
+		for in := buf[:n]; len(in) > 0; {
+			key, size := parseKey(in)
+			in = in[size:]
+			if key != "" {
+				keys <- key
+			}
+		}
+	}
+}
+
+func parseKey(in []byte) (string, int) {
+	if in[0] == 0x1b {
+		if len(in) >= 3 && in[1] == '[' {
+			switch in[2] {
+			case 'A':
+				return "up", 3
+			case 'B':
+				return "down", 3
+			case 'C':
+				return "enter", 3
+			case 'D':
+				return "back", 3
+			case 'H':
+				return "home", 3
+			case 'F':
+				return "end", 3
+			case '5', '6':
+				if len(in) >= 4 && in[3] == '~' {
+					if in[2] == '5' {
+						return "pgup", 4
+					}
+					return "pgdn", 4
+				}
+			}
+			// 未识别的转义序列：跳过到结束字节
+			for i := 2; i < len(in); i++ {
+				if in[i] >= '@' && in[i] <= '~' {
+					return "", i + 1
+				}
+			}
+			return "", len(in)
+		}
+		return "back", 1
+	}
+
+	switch in[0] {
+	case 'q', 'Q', 3: // 3 为 Ctrl-C，原始模式下不会产生信号
+		return "quit", 1
+	case 'k':
+		return "up", 1
+	case 'j':
+		return "down", 1
+	case 'l', '\r', '\n':
+		return "enter", 1
+	case 'h', 0x7f, 0x08:
+		return "back", 1
+	case 's':
+		return "sort", 1
+	case 'r':
+		return "refresh", 1
+	case 'g':
+		return "home", 1
+	case 'G':
+		return "end", 1
+	}
+	return "", 1
+}
@@ -0,0 +1,68 @@
+// Command monitor-top is a terminal client for the monitor server: a live
+// server list and a per-server detail pane with sparklines, refreshed on an
+// interval.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/monitor-system/pkg/client"
+)
+
+type options struct {
+	server   string
+	apiKey   string
+	interval time.Duration
+	span     time.Duration
+	timeout  time.Duration
+}
+
+func main() {
+	opts := &options{}
+	flag.StringVar(&opts.server, "server", envOr("MONITOR_SERVER", "http://localhost:8080"), "Server endpoint (env MONITOR_SERVER)")
+	flag.StringVar(&opts.apiKey, "api-key", os.Getenv("MONITOR_API_KEY"), "API key (env MONITOR_API_KEY)")
+	flag.DurationVar(&opts.interval, "interval", 5*time.Second, "Refresh interval")
+	flag.DurationVar(&opts.span, "span", time.Hour, "History shown by the detail sparklines")
+	flag.DurationVar(&opts.timeout, "timeout", 10*time.Second, "HTTP request timeout")
+	flag.Parse()
+
+	if opts.apiKey == "" {
+		log.Fatal("API key is required, use -api-key or MONITOR_API_KEY")
+	}
+	if opts.interval <= 0 || opts.span <= 0 {
+		log.Fatal("interval and span must be positive")
+	}
+
+	c := client.New(opts.server, opts.apiKey)
+	c.HTTPClient.Timeout = opts.timeout
+	c.MaxRetries = 0 // 界面按间隔刷新，失败的请求不必重试
+	// 先请求一次，尽早暴露地址或密钥错误
+	if err := c.VerifyAuth(context.Background()); err != nil {
+		log.Fatalf("Failed to connect to %s: %v", opts.server, err)
+	}
+
+	restore, err := makeRaw()
+	if err != nil {
+		log.Fatalf("Failed to set up terminal: %v", err)
+	}
+	// 切换到备用屏幕并隐藏光标，退出时恢复
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		restore()
+	}()
+
+	newApp(c, opts).run()
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
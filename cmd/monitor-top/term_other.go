@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import "os"
+
+// On other platforms the terminal stays in line mode: keys take effect after
+// Enter.
+func makeRaw() (func(), error) {
+	return func() {}, nil
+}
+
+func terminalSize() (int, int) {
+	return 80, 24
+}
+
+func notifyResize(ch chan<- os.Signal) {}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/monitor-system/pkg/api"
+)
+
+const (
+	reset   = "\x1b[0m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	inverse = "\x1b[7m"
+	red     = "\x1b[31m"
+	green   = "\x1b[32m"
+	yellow  = "\x1b[33m"
+	blue    = "\x1b[34m"
+	cyan    = "\x1b[36m"
+)
+
+// screen collects the lines of one frame. Lines are clipped to the terminal
+// width by display cells, ignoring escape sequences.
+type screen struct {
+	width, height int
+	lines         []string
+}
+
+func (s *screen) add(format string, args ...interface{}) {
+	s.lines = append(s.lines, clip(fmt.Sprintf(format, args...), s.width))
+}
+
+func (s *screen) blank() {
+	s.lines = append(s.lines, "")
+}
+
+// String renders the frame: home the cursor, draw every line and clear what
+// is left of the previous frame.
+func (s *screen) String() string {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range s.lines {
+		if i >= s.height {
+			break
+		}
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString(reset + "\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	return b.String()
+}
+
+// cellWidth approximates the number of terminal cells r occupies: East Asian
+// wide characters take two.
+func cellWidth(r rune) int {
+	switch {
+	case r < 0x1100:
+		return 1
+	case r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f, r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6, r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// clip cuts s after width cells, keeping escape sequences intact.
+func clip(s string, width int) string {
+	var b strings.Builder
+	cells := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			end := strings.IndexFunc(s[i+1:], func(r rune) bool { return r >= '@' && r <= '~' && r != '[' })
+			if end < 0 {
+				break
+			}
+			b.WriteString(s[i : i+end+2])
+			i += end + 2
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if cells+cellWidth(r) > width {
+			break
+		}
+		cells += cellWidth(r)
+		b.WriteRune(r)
+		i += size
+	}
+	return b.String()
+}
+
+// pad truncates or right-pads plain text to exactly width cells.
+func pad(s string, width int) string {
+	s = clip(s, width)
+	cells := 0
+	for _, r := range s {
+		cells += cellWidth(r)
+	}
+	return s + strings.Repeat(" ", width-cells)
+}
+
+func padLeft(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+func statusColor(status string) string {
+	switch status {
+	case "online":
+		return green
+	case "offline":
+		return red
+	case "warning":
+		return yellow
+	case "maintenance":
+		return blue
+	default:
+		return ""
+	}
+}
+
+func percentColor(v float64) string {
+	switch {
+	case v >= 90:
+		return red
+	case v >= 70:
+		return yellow
+	default:
+		return ""
+	}
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one cell per value scaled to top; NaN is left blank. A
+// zero top scales to the largest value.
+func sparkline(values []float64, top float64) string {
+	if top <= 0 {
+		for _, v := range values {
+			if !math.IsNaN(v) && v > top {
+				top = v
+			}
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		if math.IsNaN(v) {
+			b.WriteRune(' ')
+			continue
+		}
+		idx := 0
+		if top > 0 {
+			idx = int(math.Round(v / top * float64(len(sparks)-1)))
+		}
+		idx = min(len(sparks)-1, idx)
+		b.WriteRune(sparks[max(0, idx)])
+	}
+	return b.String()
+}
+
+// series places samples into n step-wide slots ending at end, averaging
+// samples that share a slot. Empty slots are NaN.
+func series(samples []api.Metrics, value func(s *api.Metrics) float64, end time.Time, step time.Duration, n int) []float64 {
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	start := end.Add(-time.Duration(n) * step)
+	for i := range samples {
+		idx := int(samples[i].Timestamp.Sub(start) / step)
+		if samples[i].Timestamp.Before(start) || idx >= n {
+			continue
+		}
+		sums[idx] += value(&samples[i])
+		counts[idx]++
+	}
+	out := make([]float64, n)
+	for i := range out {
+		if counts[i] == 0 {
+			out[i] = math.NaN()
+		} else {
+			out[i] = sums[i] / float64(counts[i])
+		}
+	}
+	return out
+}
+
+func last(values []float64) float64 {
+	for i := len(values) - 1; i >= 0; i-- {
+		if !math.IsNaN(values[i]) {
+			return values[i]
+		}
+	}
+	return math.NaN()
+}
+
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// size formats a disk size reported in MB.
+func size(mb uint64) string {
+	switch {
+	case mb < 1024:
+		return fmt.Sprintf("%dM", mb)
+	case mb < 1024*1024:
+		return fmt.Sprintf("%.1fG", float64(mb)/1024)
+	default:
+		return fmt.Sprintf("%.1fT", float64(mb)/1024/1024)
+	}
+}
+
+func uptime(seconds int64) string {
+	d := seconds / 86400
+	h := seconds % 86400 / 3600
+	m := seconds % 3600 / 60
+	if d > 0 {
+		return fmt.Sprintf("%dd %dh", d, h)
+	}
+	return fmt.Sprintf("%dh %dm", h, m)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/monitor-system/internal/server/backup"
+	"github.com/monitor-system/internal/server/config"
+	"github.com/monitor-system/internal/server/database"
+)
+
+// runBackup implements "monitor-server backup", which snapshots the database
+// into the backup directory. It is safe to run while the server is running.
+func runBackup(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	dir := fs.String("dir", cfg.Backup.Dir, "Backup directory")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monitor-server [-config path] backup [-dir path]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if !cfg.Database.IsSQLite() {
+		log.Fatalf("Backups are only supported with the sqlite driver")
+	}
+
+	db, err := database.New(cfg.Database.Source())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	info, err := backup.New(db, *dir, cfg.Backup.Keep).Create()
+	if err != nil {
+		log.Fatalf("Backup failed: %v", err)
+	}
+	fmt.Printf("Created %s (%d bytes)\n", info.Name, info.Size)
+}
+
+// runRestore implements "monitor-server restore <file>". The server must be
+// stopped first; the restore is refused while SQLite's -wal/-shm files show
+// the database is open.
+func runRestore(cfg *config.Config, args []string) {
+	if len(args) != 1 {
+		log.Fatalf("Usage: monitor-server [-config path] restore <backup file>")
+	}
+	if !cfg.Database.IsSQLite() {
+		log.Fatalf("Restore is only supported with the sqlite driver")
+	}
+
+	version, err := database.VerifySQLiteFile(args[0])
+	if err != nil {
+		log.Fatalf("Invalid backup: %v", err)
+	}
+
+	previous, err := backup.Restore(args[0], cfg.Database.File())
+	if err != nil {
+		log.Fatalf("Restore failed: %v", err)
+	}
+
+	fmt.Printf("Restored %s (schema version %d) to %s\n", args[0], version, cfg.Database.File())
+	if previous != "" {
+		fmt.Printf("Previous database saved as %s\n", previous)
+	}
+	if version < database.LatestSchemaVersion() {
+		fmt.Printf("Schema will be migrated to version %d on next start\n", database.LatestSchemaVersion())
+	}
+}
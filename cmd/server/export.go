@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/internal/server/config"
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/export"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/query"
+	"github.com/monitor-system/internal/server/selector"
+)
+
+// exportOptions are the flags of "monitor-server export".
+type exportOptions struct {
+	servers, selector string
+	start, end        string
+	duration          time.Duration
+	format, output    string
+}
+
+// runExport implements "monitor-server export", which writes metrics straight
+// from the database without a running server.
+func runExport(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	servers := fs.String("servers", "", "Comma-separated server IDs (default: all)")
+	selectorText := fs.String("selector", "", "Label selector, e.g. env=prod,role!=db")
+	startText := fs.String("start", "", "Start time, RFC3339 or Unix seconds (default: end - duration)")
+	endText := fs.String("end", "", "End time, RFC3339 or Unix seconds (default: now)")
+	duration := fs.Duration("duration", 24*time.Hour, "Time span when start is not given")
+	format := fs.String("format", export.FormatCSV, "Output format: csv or ndjson")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monitor-server [-config path] export [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	err := exportMetrics(cfg, exportOptions{
+		servers: *servers, selector: *selectorText,
+		start: *startText, end: *endText, duration: *duration,
+		format: *format, output: *output,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// exportMetrics writes the export, removing a partially written output file
+// when it fails.
+func exportMetrics(cfg *config.Config, opts exportOptions) (err error) {
+	end := time.Now().UTC()
+	if opts.end != "" {
+		if end, err = query.ParseTime(opts.end); err != nil {
+			return err
+		}
+	}
+	start := end.Add(-opts.duration)
+	if opts.start != "" {
+		if start, err = query.ParseTime(opts.start); err != nil {
+			return err
+		}
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("start must be before end")
+	}
+
+	sel, err := selector.Parse(opts.selector)
+	if err != nil {
+		return err
+	}
+
+	store, err := database.Open(cfg.Database.Driver, cfg.Database.Source())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	all, err := store.GetServers()
+	if err != nil {
+		return fmt.Errorf("failed to load servers: %w", err)
+	}
+	// 导出全部服务器时不按 ID 过滤，避免超出 SQLite 的参数数量限制
+	filtered := opts.servers != "" || strings.TrimSpace(opts.selector) != ""
+	selected := all
+	if filtered {
+		if selected, err = selector.Select(all, selector.SplitIDs(opts.servers), sel); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]string, len(selected))
+	var ids []string
+	for _, s := range selected {
+		names[s.ID] = s.Name
+		if filtered {
+			ids = append(ids, s.ID)
+		}
+	}
+
+	out := os.Stdout
+	if opts.output != "" {
+		f, createErr := os.Create(opts.output)
+		if createErr != nil {
+			return fmt.Errorf("failed to create output file: %w", createErr)
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(opts.output)
+			}
+		}()
+		out = f
+	}
+
+	w, err := export.New(opts.format, out, names)
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	if len(selected) > 0 {
+		err = store.EachMetric(ids, start, end, func(m *model.Metrics) error {
+			rows++
+			return w.Write(m)
+		})
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("export failed after %d rows: %w", rows, err)
+	}
+	if opts.output != "" {
+		log.Printf("Exported %d rows from %d servers to %s", rows, len(selected), opts.output)
+	}
+	return nil
+}
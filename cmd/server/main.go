@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/anomaly"
+	"github.com/monitor-system/internal/server/backup"
 	"github.com/monitor-system/internal/server/config"
 	"github.com/monitor-system/internal/server/database"
 	"github.com/monitor-system/internal/server/handler"
+	"github.com/monitor-system/internal/server/ingest"
+	"github.com/monitor-system/internal/server/maintenance"
 	"github.com/monitor-system/internal/server/middleware"
+	"github.com/monitor-system/internal/server/report"
+	"github.com/monitor-system/internal/server/retention"
+	"github.com/monitor-system/internal/server/slo"
+	"github.com/monitor-system/internal/server/web"
 )
 
 func main() {
@@ -23,8 +36,23 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	switch flag.Arg(0) {
+	case "migrate":
+		runMigrate(cfg, flag.Args()[1:])
+		return
+	case "export":
+		runExport(cfg, flag.Args()[1:])
+		return
+	case "backup":
+		runBackup(cfg, flag.Args()[1:])
+		return
+	case "restore":
+		runRestore(cfg, flag.Args()[1:])
+		return
+	}
+
 	// Initialize database
-	db, err := database.New(cfg.Database.Path)
+	db, err := database.Open(cfg.Database.Driver, cfg.Database.Source())
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -34,8 +62,56 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	var backups *backup.Manager
+	if b, ok := db.(database.Backuper); ok && cfg.Database.IsSQLite() {
+		backups = backup.New(b, cfg.Backup.Dir, cfg.Backup.Keep)
+	}
+
+	cleaner, err := retention.New(db, cfg.Retention)
+	if err != nil {
+		log.Fatalf("Invalid retention config: %v", err)
+	}
+
+	reporter, err := slo.New(db, cfg.SLO)
+	if err != nil {
+		log.Fatalf("Invalid slo config: %v", err)
+	}
+
+	reportOpts := report.Options{
+		Dir:                cfg.Report.Dir,
+		Keep:               cfg.Report.Keep,
+		TopN:               cfg.Report.TopN,
+		DiskThreshold:      cfg.Report.DiskThreshold,
+		AvailabilityTarget: cfg.Report.AvailabilityTarget,
+		Slot:               time.Duration(cfg.SLO.Slot) * time.Second,
+	}
+	if smtp := cfg.Report.SMTP; smtp.Host != "" && len(smtp.To) > 0 {
+		reportOpts.SMTP = &report.SMTP{
+			Host: smtp.Host, Port: smtp.Port, Username: smtp.Username,
+			Password: smtp.Password, From: smtp.From, To: smtp.To,
+		}
+	}
+	reports := report.New(db, reportOpts)
+
+	var detector *anomaly.Detector
+	if cfg.Anomaly.Enabled {
+		detector, err = anomaly.New(db, anomaly.Options{
+			Metrics:    cfg.Anomaly.Metrics,
+			Sigma:      cfg.Anomaly.Sigma,
+			MinSamples: cfg.Anomaly.MinSamples,
+			MinStdDev:  cfg.Anomaly.MinStdDev,
+			History:    time.Duration(cfg.Anomaly.HistoryDays) * 24 * time.Hour,
+			Lookback:   time.Duration(cfg.Anomaly.Lookback) * time.Minute,
+			MergeGap:   5 * time.Minute,
+			Delay:      30 * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Invalid anomaly config: %v", err)
+		}
+	}
+
 	// Start background tasks
-	go startBackgroundTasks(db, cfg)
+	go startBackgroundTasks(db, backups, cleaner, detector, reports, cfg)
 
 	// Setup HTTP server
 	if cfg.Logging.Level != "debug" {
@@ -45,20 +121,67 @@ func main() {
 	r := gin.Default()
 	r.Use(middleware.CORSMiddleware())
 
-	h := handler.New(db)
+	// Built-in dashboard
+	web.Register(r, "/ui")
+
+	// Agent reports are written in batches by a single writer
+	queue := ingest.New(db, ingest.Options{
+		QueueSize:     cfg.Ingest.QueueSize,
+		BatchSize:     cfg.Ingest.BatchSize,
+		FlushInterval: time.Duration(cfg.Ingest.FlushInterval) * time.Millisecond,
+	})
+
+	h := handler.New(db, queue, backups, cleaner, reporter, reports)
 
 	// Frontend API (requires API Key)
 	api := r.Group("/api/v1")
 	api.Use(middleware.AuthMiddleware(cfg.Auth.APIKey))
 	{
 		api.POST("/auth/verify", h.VerifyAuth)
+		api.GET("/overview", h.GetOverview)
 		api.GET("/servers", h.GetServers)
 		api.GET("/servers/:id", h.GetServerDetail)
 		api.DELETE("/servers/:id", h.DeleteServer)
+		api.POST("/servers/:id/archive", h.ArchiveServer)
+		api.POST("/servers/:id/maintenance", h.StartMaintenance)
+		api.POST("/servers/:id/restore", h.RestoreServer)
+		api.GET("/maintenance", h.GetMaintenanceWindows)
+		api.POST("/maintenance", h.CreateMaintenanceWindow)
+		api.DELETE("/maintenance/:id", h.DeleteMaintenanceWindow)
+		api.GET("/blocked", h.GetBlockedServers)
+		api.POST("/blocked", h.BlockServer)
+		api.DELETE("/blocked/:id", h.UnblockServer)
 		api.GET("/servers/:id/history", h.GetHistory)
 		api.GET("/servers/:id/disks", h.GetDisks)
 		api.GET("/servers/:id/processes", h.GetProcesses)
 		api.GET("/servers/:id/network", h.GetNetwork)
+		api.GET("/servers/:id/agent", h.GetAgentStatus)
+		api.GET("/servers/:id/sensors", h.GetSensors)
+		api.GET("/servers/:id/packages", h.GetPackages)
+		api.GET("/servers/:id/packages/history", h.GetPackageHistory)
+		api.GET("/servers/:id/anomalies", h.GetAnomalies)
+		api.GET("/query", h.Query)
+		api.GET("/export/metrics", h.ExportMetrics)
+		api.GET("/inventory", h.GetInventory)
+		api.GET("/packages", h.SearchPackages)
+		api.GET("/ingest/stats", h.GetIngestStats)
+		api.GET("/slo", h.GetSLOs)
+		api.GET("/slo/report", h.GetSLOReport)
+		api.GET("/reports", h.ListReports)
+		api.POST("/reports", h.CreateReport)
+		api.GET("/reports/:name", h.DownloadReport)
+		api.POST("/reports/:name/send", h.SendReport)
+	}
+
+	// Admin API (requires Admin Key)
+	admin := r.Group("/api/v1/admin")
+	admin.Use(middleware.AdminAuthMiddleware(cfg.Auth.AdminKey))
+	{
+		admin.GET("/backups", h.ListBackups)
+		admin.POST("/backups", h.CreateBackup)
+		admin.GET("/backups/:name", h.DownloadBackup)
+		admin.GET("/storage", h.GetStorage)
+		admin.POST("/cleanup", h.RunCleanup)
 	}
 
 	// Agent API (requires Agent Key)
@@ -70,32 +193,125 @@ func main() {
 
 	// Start server
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	log.Printf("Starting server on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{Addr: addr, Handler: r}
+	go func() {
+		log.Printf("Starting server on %s", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for shutdown signal, then flush queued reports
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
 	}
+	queue.Close()
 }
 
-func startBackgroundTasks(db *database.DB, cfg *config.Config) {
+func startBackgroundTasks(db database.Store, backups *backup.Manager, cleaner *retention.Manager,
+	detector *anomaly.Detector, reports *report.Manager, cfg *config.Config) {
+
 	// Update server status every 10 seconds
 	statusTicker := time.NewTicker(10 * time.Second)
 	go func() {
 		for range statusTicker.C {
-			if err := db.UpdateServerStatus(); err != nil {
+			inMaintenance, err := maintenance.ActiveServers(db, time.Now())
+			if err != nil {
+				log.Printf("Failed to check maintenance windows: %v", err)
+			}
+			if err := db.UpdateServerStatus(inMaintenance); err != nil {
 				log.Printf("Failed to update server status: %v", err)
 			}
 		}
 	}()
 
-	// Cleanup old data daily
+	// Archive servers that have been offline too long
+	if cfg.Lifecycle.ArchiveAfter > 0 {
+		offlineFor := time.Duration(cfg.Lifecycle.ArchiveAfter) * time.Hour
+		archiveTicker := time.NewTicker(time.Hour)
+		go func() {
+			for {
+				if n, err := db.ArchiveStaleServers(time.Now().Add(-offlineFor)); err != nil {
+					log.Printf("Failed to archive stale servers: %v", err)
+				} else if n > 0 {
+					log.Printf("Archived %d servers offline for more than %s", n, offlineFor)
+				}
+				<-archiveTicker.C
+			}
+		}()
+	}
+
+	// Apply retention policies at startup and then periodically
 	cleanupTicker := time.NewTicker(time.Duration(cfg.Data.CleanupInterval) * time.Hour)
 	go func() {
-		for range cleanupTicker.C {
-			if err := db.CleanupOldData(cfg.Data.RetentionDays); err != nil {
-				log.Printf("Failed to cleanup old data: %v", err)
+		for {
+			result := cleaner.Run()
+			if result.Error != "" {
+				log.Printf("Failed to cleanup old data: %s", result.Error)
 			} else {
-				log.Printf("Cleaned up data older than %d days", cfg.Data.RetentionDays)
+				log.Printf("Cleaned up old data: %v deleted in %s (vacuumed: %v)",
+					result.Deleted, result.Duration, result.Vacuumed)
 			}
+			<-cleanupTicker.C
 		}
 	}()
+
+	// Anomaly detection
+	if detector != nil {
+		anomalyTicker := time.NewTicker(time.Duration(cfg.Anomaly.Interval) * time.Second)
+		go func() {
+			for range anomalyTicker.C {
+				if n, err := detector.Run(time.Now()); err != nil {
+					log.Printf("Anomaly detection failed: %v", err)
+				} else if n > 0 {
+					log.Printf("Detected %d new anomalies", n)
+				}
+			}
+		}()
+	}
+
+	// Daily and weekly reports, generated once the period has ended
+	var periods []string
+	if cfg.Report.Daily {
+		periods = append(periods, report.PeriodDaily)
+	}
+	if cfg.Report.Weekly {
+		periods = append(periods, report.PeriodWeekly)
+	}
+	if len(periods) > 0 {
+		reportTicker := time.NewTicker(10 * time.Minute)
+		go func() {
+			for {
+				generated, err := reports.RunDue(periods, time.Now())
+				for _, info := range generated {
+					log.Printf("Generated report %s", info.Name)
+				}
+				if err != nil {
+					log.Printf("Failed to generate reports: %v", err)
+				}
+				<-reportTicker.C
+			}
+		}()
+	}
+
+	// Scheduled backups
+	if backups != nil && cfg.Backup.Interval > 0 {
+		backupTicker := time.NewTicker(time.Duration(cfg.Backup.Interval) * time.Hour)
+		go func() {
+			for range backupTicker.C {
+				if info, err := backups.Create(); err != nil {
+					log.Printf("Failed to create backup: %v", err)
+				} else {
+					log.Printf("Created backup %s (%d bytes)", info.Name, info.Size)
+				}
+			}
+		}()
+	}
 }
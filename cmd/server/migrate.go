@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/monitor-system/internal/server/config"
+	"github.com/monitor-system/internal/server/database"
+)
+
+// runMigrate implements "monitor-server migrate status|up".
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) != 1 || (args[0] != "status" && args[0] != "up") {
+		log.Fatalf("Usage: monitor-server [-config path] migrate status|up")
+	}
+
+	store, err := database.Open(cfg.Database.Driver, cfg.Database.Source())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer store.Close()
+
+	migrator, ok := store.(database.Migrator)
+	if !ok {
+		log.Fatalf("Database driver %q does not use migrations", cfg.Database.Driver)
+	}
+
+	if args[0] == "up" {
+		applied, err := migrator.Migrate()
+		for _, m := range applied {
+			fmt.Printf("Applied %d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+		return
+	}
+
+	status, err := migrator.MigrationStatus()
+	if err != nil {
+		log.Fatalf("Failed to read migration status: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range status {
+		appliedAt := "pending"
+		if s.Applied {
+			appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	w.Flush()
+}
@@ -1,7 +1,11 @@
 package collector
 
 import (
+	"fmt"
+	"path"
+	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/monitor-system/internal/server/model"
@@ -13,20 +17,94 @@ import (
 	"github.com/shirou/gopsutil/v3/process"
 )
 
+const defaultSysfsRoot = "/sys"
+
+// Options filters what the collector reports.
+type Options struct {
+	Mountpoints      []string // 挂载点匹配模式（path.Match），为空表示全部
+	IgnoreFSTypes    []string
+	InterfaceInclude string // 网卡名称正则
+	InterfaceExclude string
+	SysfsRoot        string // 读取传感器的 sysfs 根目录，默认 /sys
+}
+
 type Collector struct {
-	lastNetIO   map[string]net.IOCountersStat
-	lastDiskIO  map[string]disk.IOCountersStat
-	lastTime    time.Time
-	lastNetTime map[string]time.Time // 每个网卡独立的时间戳
+	lastNetIO    map[string]net.IOCountersStat // CollectNetwork 使用
+	lastNetTotal map[string]net.IOCountersStat // CollectMetrics 使用
+	lastDiskIO   map[string]disk.IOCountersStat
+	lastTime     time.Time
+	lastNetTime  map[string]time.Time // 每个网卡独立的时间戳
+
+	mountpoints   []string
+	ignoreFSTypes map[string]bool
+	ifaceInclude  *regexp.Regexp
+	ifaceExclude  *regexp.Regexp
+	sysfsRoot     string
 }
 
-func New() *Collector {
-	return &Collector{
-		lastNetIO:   make(map[string]net.IOCountersStat),
-		lastDiskIO:  make(map[string]disk.IOCountersStat),
-		lastTime:    time.Now(),
-		lastNetTime: make(map[string]time.Time),
+func New(opts Options) (*Collector, error) {
+	c := &Collector{
+		lastNetIO:     make(map[string]net.IOCountersStat),
+		lastNetTotal:  make(map[string]net.IOCountersStat),
+		lastDiskIO:    make(map[string]disk.IOCountersStat),
+		lastTime:      time.Now(),
+		lastNetTime:   make(map[string]time.Time),
+		mountpoints:   opts.Mountpoints,
+		ignoreFSTypes: make(map[string]bool),
+		sysfsRoot:     opts.SysfsRoot,
+	}
+	if c.sysfsRoot == "" {
+		c.sysfsRoot = defaultSysfsRoot
+	}
+
+	for _, pattern := range opts.Mountpoints {
+		if _, err := path.Match(pattern, "/"); err != nil {
+			return nil, fmt.Errorf("invalid mountpoint pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, fsType := range opts.IgnoreFSTypes {
+		c.ignoreFSTypes[fsType] = true
+	}
+
+	var err error
+	if opts.InterfaceInclude != "" {
+		if c.ifaceInclude, err = regexp.Compile(opts.InterfaceInclude); err != nil {
+			return nil, fmt.Errorf("invalid interface include pattern: %w", err)
+		}
+	}
+	if opts.InterfaceExclude != "" {
+		if c.ifaceExclude, err = regexp.Compile(opts.InterfaceExclude); err != nil {
+			return nil, fmt.Errorf("invalid interface exclude pattern: %w", err)
+		}
 	}
+
+	return c, nil
+}
+
+func (c *Collector) wantInterface(name string) bool {
+	if c.ifaceInclude != nil && !c.ifaceInclude.MatchString(name) {
+		return false
+	}
+	if c.ifaceExclude != nil && c.ifaceExclude.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+func (c *Collector) wantPartition(p disk.PartitionStat) bool {
+	if c.ignoreFSTypes[p.Fstype] {
+		return false
+	}
+	if len(c.mountpoints) == 0 {
+		return true
+	}
+	for _, pattern := range c.mountpoints {
+		if ok, _ := path.Match(pattern, p.Mountpoint); ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *Collector) CollectMetrics() (*model.Metrics, error) {
@@ -68,7 +146,7 @@ func (c *Collector) CollectMetrics() (*model.Metrics, error) {
 		}
 	}
 
-	// Network - 排除 loopback 接口，只统计真实网络流量
+	// Network - 按网卡过滤规则排除 loopback 等接口，只统计真实网络流量
 	netIO, err := net.IOCounters(true) // true 表示获取每个接口的统计
 	if err == nil && len(netIO) > 0 {
 		now := time.Now()
@@ -77,10 +155,9 @@ func (c *Collector) CollectMetrics() (*model.Metrics, error) {
 		var totalBytesRecv, totalBytesSent uint64
 		var lastTotalRecv, lastTotalSent uint64
 
-		// 汇总所有非 loopback 接口的流量
+		// 汇总所有被采集接口的流量
 		for _, io := range netIO {
-			// 跳过 loopback 接口
-			if io.Name == "lo" || io.Name == "lo0" {
+			if !c.wantInterface(io.Name) {
 				continue
 			}
 
@@ -88,13 +165,13 @@ func (c *Collector) CollectMetrics() (*model.Metrics, error) {
 			totalBytesSent += io.BytesSent
 
 			// 获取上次的数据
-			if last, ok := c.lastNetIO[io.Name]; ok {
+			if last, ok := c.lastNetTotal[io.Name]; ok {
 				lastTotalRecv += last.BytesRecv
 				lastTotalSent += last.BytesSent
 			}
 
 			// 更新该接口的历史数据
-			c.lastNetIO[io.Name] = io
+			c.lastNetTotal[io.Name] = io
 		}
 
 		// 计算速度 (MB/s)
@@ -131,6 +208,68 @@ func (c *Collector) CollectServerInfo() (*model.ServerInfo, error) {
 	return info, nil
 }
 
+// OSName describes the distro and version, e.g. "ubuntu 22.04", the same way
+// as the inventory. It falls back to runtime.GOOS when they are unknown.
+func (c *Collector) OSName() string {
+	hostInfo, err := host.Info()
+	if err != nil || hostInfo.Platform == "" {
+		return runtime.GOOS
+	}
+	return strings.TrimSpace(hostInfo.Platform + " " + hostInfo.PlatformVersion)
+}
+
+// CollectInventory gathers slow-changing host details: distro, kernel,
+// virtualization, CPU model and total capacity.
+func (c *Collector) CollectInventory() (*model.Inventory, error) {
+	hostInfo, err := host.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &model.Inventory{
+		Hostname:             hostInfo.Hostname,
+		OS:                   hostInfo.OS,
+		Platform:             hostInfo.Platform,
+		PlatformFamily:       hostInfo.PlatformFamily,
+		PlatformVersion:      hostInfo.PlatformVersion,
+		KernelVersion:        hostInfo.KernelVersion,
+		KernelArch:           hostInfo.KernelArch,
+		VirtualizationSystem: hostInfo.VirtualizationSystem,
+		VirtualizationRole:   hostInfo.VirtualizationRole,
+		BootTime:             time.Unix(int64(hostInfo.BootTime), 0),
+		CPUCores:             runtime.NumCPU(),
+	}
+
+	cpuInfo, err := cpu.Info()
+	if err == nil && len(cpuInfo) > 0 {
+		inv.CPUModel = cpuInfo[0].ModelName
+	}
+
+	vmStat, err := mem.VirtualMemory()
+	if err == nil {
+		inv.TotalMemory = int64(vmStat.Total / 1024 / 1024) // MB
+	}
+
+	// 同一设备可能挂载多次，按设备去重
+	partitions, err := disk.Partitions(false)
+	if err == nil {
+		seen := make(map[string]bool)
+		for _, partition := range partitions {
+			if !c.wantPartition(partition) || seen[partition.Device] {
+				continue
+			}
+			usage, err := disk.Usage(partition.Mountpoint)
+			if err != nil {
+				continue
+			}
+			seen[partition.Device] = true
+			inv.TotalDisk += usage.Total / 1024 / 1024 // MB
+		}
+	}
+
+	return inv, nil
+}
+
 func (c *Collector) CollectDisks() ([]model.Disk, error) {
 	partitions, err := disk.Partitions(false)
 	if err != nil {
@@ -139,6 +278,10 @@ func (c *Collector) CollectDisks() ([]model.Disk, error) {
 
 	var disks []model.Disk
 	for _, partition := range partitions {
+		if !c.wantPartition(partition) {
+			continue
+		}
+
 		usage, err := disk.Usage(partition.Mountpoint)
 		if err != nil {
 			continue
@@ -232,8 +375,7 @@ func (c *Collector) CollectNetwork() ([]model.NetworkInterface, error) {
 	var interfaces []model.NetworkInterface
 
 	for _, io := range netIO {
-		// Skip loopback
-		if io.Name == "lo" || io.Name == "lo0" {
+		if !c.wantInterface(io.Name) {
 			continue
 		}
 
@@ -0,0 +1,293 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+// VersionCommand reports the version of a binary that is not managed by the
+// system package manager.
+type VersionCommand struct {
+	Name    string
+	Command []string
+	Pattern string // 第一个捕获组为版本号；为空时取输出第一行
+}
+
+type PackageOptions struct {
+	DpkgStatus   string // dpkg 状态文件路径
+	RPM          bool   // 可用时通过 rpm -qa 读取
+	Commands     []VersionCommand
+	FullInterval time.Duration // 定期发送完整列表
+}
+
+type versionCommand struct {
+	VersionCommand
+	re *regexp.Regexp
+}
+
+// PackageCollector reads installed packages and reports only the differences
+// from the list the server last acknowledged.
+type PackageCollector struct {
+	dpkgStatus   string
+	rpm          bool
+	commands     []versionCommand
+	fullInterval time.Duration
+
+	known    map[string]model.Package // 服务端已确认的软件包
+	lastFull time.Time
+}
+
+func NewPackageCollector(opts PackageOptions) (*PackageCollector, error) {
+	p := &PackageCollector{
+		dpkgStatus:   opts.DpkgStatus,
+		rpm:          opts.RPM,
+		fullInterval: opts.FullInterval,
+	}
+
+	for _, cmd := range opts.Commands {
+		if cmd.Name == "" || len(cmd.Command) == 0 {
+			return nil, fmt.Errorf("version command requires name and command")
+		}
+		vc := versionCommand{VersionCommand: cmd}
+		if cmd.Pattern != "" {
+			re, err := regexp.Compile(cmd.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern for %s: %w", cmd.Name, err)
+			}
+			vc.re = re
+		}
+		p.commands = append(p.commands, vc)
+	}
+
+	return p, nil
+}
+
+// Collect returns the package changes to send, or nil when nothing changed.
+func (p *PackageCollector) Collect() (*model.PackageReport, error) {
+	current, err := p.installed()
+	if err != nil {
+		return nil, err
+	}
+
+	full := p.known == nil || (p.fullInterval > 0 && time.Since(p.lastFull) >= p.fullInterval)
+	if full {
+		return &model.PackageReport{Full: true, Upserted: current}, nil
+	}
+
+	report := &model.PackageReport{}
+	seen := make(map[string]bool, len(current))
+	for _, pkg := range current {
+		key := pkg.Key()
+		seen[key] = true
+		if old, ok := p.known[key]; !ok || old.Version != pkg.Version {
+			report.Upserted = append(report.Upserted, pkg)
+		}
+	}
+	for key, pkg := range p.known {
+		if !seen[key] {
+			report.Removed = append(report.Removed, pkg)
+		}
+	}
+
+	if len(report.Upserted) == 0 && len(report.Removed) == 0 {
+		return nil, nil
+	}
+	sortPackages(report.Removed)
+
+	return report, nil
+}
+
+// Acknowledge records a report the server has accepted.
+func (p *PackageCollector) Acknowledge(report *model.PackageReport) {
+	if report == nil {
+		return
+	}
+
+	if report.Full {
+		p.known = make(map[string]model.Package, len(report.Upserted))
+		p.lastFull = time.Now()
+	}
+	for _, pkg := range report.Upserted {
+		p.known[pkg.Key()] = pkg
+	}
+	for _, pkg := range report.Removed {
+		delete(p.known, pkg.Key())
+	}
+}
+
+// Reset forgets what the server has confirmed, so the next Collect sends the
+// full package list.
+func (p *PackageCollector) Reset() {
+	p.known = nil
+}
+
+func (p *PackageCollector) installed() ([]model.Package, error) {
+	var packages []model.Package
+
+	if p.dpkgStatus != "" {
+		dpkg, err := readDpkgStatus(p.dpkgStatus)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		packages = append(packages, dpkg...)
+	}
+
+	if p.rpm {
+		if _, err := exec.LookPath("rpm"); err == nil {
+			rpm, err := readRPM()
+			if err != nil {
+				return nil, err
+			}
+			packages = append(packages, rpm...)
+		}
+	}
+
+	for _, cmd := range p.commands {
+		version, err := cmd.version()
+		if err != nil {
+			// 二进制不存在或执行失败时忽略
+			continue
+		}
+		packages = append(packages, model.Package{Name: cmd.Name, Version: version, Source: "command"})
+	}
+
+	sortPackages(packages)
+	return packages, nil
+}
+
+func sortPackages(packages []model.Package) {
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Key() < packages[j].Key()
+	})
+}
+
+// readDpkgStatus parses the dpkg status file, keeping installed packages.
+func readDpkgStatus(path string) ([]model.Package, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var packages []model.Package
+	var pkg model.Package
+	installed := false
+
+	flush := func() {
+		if installed && pkg.Name != "" && pkg.Version != "" {
+			pkg.Source = "dpkg"
+			packages = append(packages, pkg)
+		}
+		pkg = model.Package{}
+		installed = false
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		// 续行（如 Description 的多行内容）
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Package":
+			pkg.Name = value
+		case "Version":
+			pkg.Version = value
+		case "Architecture":
+			pkg.Arch = value
+		case "Status":
+			installed = strings.HasSuffix(value, " installed")
+		}
+	}
+	flush()
+
+	return packages, scanner.Err()
+}
+
+func readRPM() ([]model.Package, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "rpm", "-qa", "--queryformat",
+		`%{NAME}\t%{EPOCH}\t%{VERSION}-%{RELEASE}\t%{ARCH}\n`).Output()
+	if err != nil {
+		return nil, fmt.Errorf("rpm -qa: %w", err)
+	}
+
+	var packages []model.Package
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+
+		version := fields[2]
+		if fields[1] != "(none)" && fields[1] != "" {
+			version = fields[1] + ":" + version
+		}
+		arch := fields[3]
+		if arch == "(none)" {
+			arch = ""
+		}
+
+		packages = append(packages, model.Package{
+			Name:    fields[0],
+			Version: version,
+			Arch:    arch,
+			Source:  "rpm",
+		})
+	}
+
+	return packages, nil
+}
+
+func (vc versionCommand) version() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 很多程序（如 nginx -v）把版本输出到 stderr
+	out, err := exec.CommandContext(ctx, vc.Command[0], vc.Command[1:]...).CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+
+	output := strings.TrimSpace(string(out))
+	if vc.re != nil {
+		match := vc.re.FindStringSubmatch(output)
+		if match == nil {
+			return "", fmt.Errorf("version not found in output of %s", vc.Name)
+		}
+		if len(match) > 1 {
+			return match[1], nil
+		}
+		return match[0], nil
+	}
+
+	line, _, _ := strings.Cut(output, "\n")
+	if line == "" {
+		return "", fmt.Errorf("empty output from %s", vc.Name)
+	}
+	return strings.TrimSpace(line), nil
+}
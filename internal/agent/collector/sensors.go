@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/monitor-system/internal/server/model"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// CollectSensors reads hwmon temperatures and fan speeds plus thermal zones
+// from sysfs. Thermal zones that are also exposed through hwmon are reported
+// once. Where the system's /sys is unavailable it falls back to gopsutil.
+func (c *Collector) CollectSensors() ([]model.Sensor, error) {
+	sensors, chips := readHwmon(filepath.Join(c.sysfsRoot, "class", "hwmon"))
+	sensors = append(sensors, readThermalZones(filepath.Join(c.sysfsRoot, "class", "thermal"), chips)...)
+
+	// gopsutil 总是读取系统的 /sys，自定义根目录时不回退
+	if len(sensors) == 0 && c.sysfsRoot == defaultSysfsRoot {
+		temps, err := host.SensorsTemperatures()
+		if err != nil && len(temps) == 0 {
+			return nil, err
+		}
+		for _, t := range temps {
+			sensors = append(sensors, model.Sensor{
+				Label:    t.SensorKey,
+				Kind:     model.SensorTemperature,
+				Value:    t.Temperature,
+				High:     t.High,
+				Critical: t.Critical,
+			})
+		}
+	}
+
+	sort.Slice(sensors, func(i, j int) bool {
+		if sensors[i].Kind != sensors[j].Kind {
+			return sensors[i].Kind > sensors[j].Kind
+		}
+		return sensors[i].Label < sensors[j].Label
+	})
+
+	return sensors, nil
+}
+
+// readHwmon returns the hwmon sensors and the set of chip names seen.
+func readHwmon(dir string) ([]model.Sensor, map[string]bool) {
+	devices, _ := filepath.Glob(filepath.Join(dir, "hwmon*"))
+
+	var sensors []model.Sensor
+	chips := make(map[string]bool)
+	for _, device := range devices {
+		// 部分驱动把传感器文件放在 device 子目录下
+		base := device
+		name := readString(filepath.Join(device, "name"))
+		if name == "" {
+			base = filepath.Join(device, "device")
+			name = readString(filepath.Join(base, "name"))
+		}
+		if name == "" {
+			name = filepath.Base(device)
+		}
+		chips[name] = true
+
+		inputs, _ := filepath.Glob(filepath.Join(base, "temp*_input"))
+		for _, input := range inputs {
+			prefix := strings.TrimSuffix(input, "_input")
+			value, ok := readMilli(input)
+			if !ok {
+				continue
+			}
+			high, _ := readMilli(prefix + "_max")
+			critical, _ := readMilli(prefix + "_crit")
+
+			sensors = append(sensors, model.Sensor{
+				Label:    sensorLabel(name, prefix),
+				Kind:     model.SensorTemperature,
+				Value:    value,
+				High:     high,
+				Critical: critical,
+			})
+		}
+
+		fans, _ := filepath.Glob(filepath.Join(base, "fan*_input"))
+		for _, input := range fans {
+			prefix := strings.TrimSuffix(input, "_input")
+			rpm, ok := readFloat(input)
+			if !ok {
+				continue
+			}
+
+			sensors = append(sensors, model.Sensor{
+				Label: sensorLabel(name, prefix),
+				Kind:  model.SensorFan,
+				Value: rpm,
+			})
+		}
+	}
+
+	return sensors, chips
+}
+
+// readThermalZones reads thermal zones, skipping those the kernel also
+// registers as hwmon devices: such a device is named after the zone type and
+// already reported by readHwmon.
+func readThermalZones(dir string, chips map[string]bool) []model.Sensor {
+	zones, _ := filepath.Glob(filepath.Join(dir, "thermal_zone*"))
+
+	var sensors []model.Sensor
+	for _, zone := range zones {
+		zoneType := readString(filepath.Join(zone, "type"))
+		if chips[zoneType] {
+			continue
+		}
+		if linked, _ := filepath.Glob(filepath.Join(zone, "hwmon*")); len(linked) > 0 {
+			continue
+		}
+
+		value, ok := readMilli(filepath.Join(zone, "temp"))
+		if !ok {
+			continue
+		}
+		if zoneType == "" {
+			zoneType = "thermal"
+		}
+
+		sensors = append(sensors, model.Sensor{
+			Label: zoneType + "/" + filepath.Base(zone),
+			Kind:  model.SensorTemperature,
+			Value: value,
+		})
+	}
+
+	return sensors
+}
+
+// sensorLabel combines the chip name with the sensor's own label, e.g.
+// "coretemp/Package id 0" or "it8728/fan1".
+func sensorLabel(chip, prefix string) string {
+	label := readString(prefix + "_label")
+	if label == "" {
+		label = filepath.Base(prefix)
+	}
+	return chip + "/" + label
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readFloat(path string) (float64, bool) {
+	value, err := strconv.ParseFloat(readString(path), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// readMilli reads a sysfs value expressed in thousandths (millidegrees).
+func readMilli(path string) (float64, bool) {
+	value, ok := readFloat(path)
+	return value / 1000, ok
+}
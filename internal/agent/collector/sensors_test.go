@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+func TestCollectSensors(t *testing.T) {
+	c, err := New(Options{SysfsRoot: "testdata/sysfs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sensors, err := c.CollectSensors()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// acpitz 和 pch_cannonlake 两个温区已经由 hwmon 上报，不应重复
+	want := []model.Sensor{
+		{Label: "acpitz/temp1", Kind: model.SensorTemperature, Value: 27.8},
+		{Label: "coretemp/Core 0", Kind: model.SensorTemperature, Value: 43},
+		{Label: "coretemp/Package id 0", Kind: model.SensorTemperature, Value: 45, High: 80, Critical: 100},
+		{Label: "iwlwifi_1/thermal_zone1", Kind: model.SensorTemperature, Value: 40},
+		{Label: "it8728/fan1", Kind: model.SensorFan, Value: 1200},
+		{Label: "it8728/fan2", Kind: model.SensorFan, Value: 0},
+	}
+	if !reflect.DeepEqual(sensors, want) {
+		t.Errorf("CollectSensors() =\n%+v\nwant\n%+v", sensors, want)
+	}
+}
+
+func TestCollectSensorsEmptyRoot(t *testing.T) {
+	c, err := New(Options{SysfsRoot: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 自定义根目录下没有传感器时不读取主机的 /sys
+	sensors, err := c.CollectSensors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sensors) != 0 {
+		t.Errorf("CollectSensors() = %+v, want none", sensors)
+	}
+}
@@ -7,16 +7,19 @@ import (
 )
 
 type Config struct {
-	Server    ServerConfig    `yaml:"server"`
-	API       APIConfig       `yaml:"api"`
-	Reporting ReportingConfig `yaml:"reporting"`
-	Logging   LoggingConfig   `yaml:"logging"`
+	Server     ServerConfig     `yaml:"server"`
+	API        APIConfig        `yaml:"api"`
+	Reporting  ReportingConfig  `yaml:"reporting"`
+	Collectors CollectorsConfig `yaml:"collectors"`
+	Health     HealthConfig     `yaml:"health"`
+	Logging    LoggingConfig    `yaml:"logging"`
 }
 
 type ServerConfig struct {
-	ID       string `yaml:"id"`
-	Name     string `yaml:"name"`
-	Location string `yaml:"location"`
+	ID       string            `yaml:"id"`
+	Name     string            `yaml:"name"`
+	Location string            `yaml:"location"`
+	Labels   map[string]string `yaml:"labels"` // 用于按标签选择服务器，如 role: web
 }
 
 type APIConfig struct {
@@ -25,7 +28,79 @@ type APIConfig struct {
 }
 
 type ReportingConfig struct {
-	Interval int `yaml:"interval"`
+	Interval     int `yaml:"interval"`      // 上报间隔（秒），同时作为心跳间隔
+	FullInterval int `yaml:"full_interval"` // 强制发送全部数据的间隔（秒）
+	QueueSize    int `yaml:"queue_size"`    // 发送失败后缓存等待重试的报告数
+}
+
+// HealthConfig controls the local troubleshooting endpoint.
+type HealthConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"`
+}
+
+// CollectorsConfig enables and schedules each collector independently.
+type CollectorsConfig struct {
+	Metrics   CollectorConfig        `yaml:"metrics"`
+	Info      CollectorConfig        `yaml:"info"`
+	Disks     DiskCollectorConfig    `yaml:"disks"`
+	Processes ProcessCollectorConfig `yaml:"processes"`
+	Network   NetworkCollectorConfig `yaml:"network"`
+	Inventory CollectorConfig        `yaml:"inventory"`
+	Packages  PackageCollectorConfig `yaml:"packages"`
+	Sensors   SensorCollectorConfig  `yaml:"sensors"`
+}
+
+type CollectorConfig struct {
+	Enabled  *bool `yaml:"enabled"`
+	Interval int   `yaml:"interval"` // 采集间隔（秒）
+}
+
+// IsEnabled reports whether the collector should run; collectors are enabled
+// unless explicitly turned off.
+func (c CollectorConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+type DiskCollectorConfig struct {
+	CollectorConfig `yaml:",inline"`
+	Mountpoints     []string `yaml:"mountpoints"`    // 只采集匹配的挂载点（支持通配符），为空表示全部
+	IgnoreFSTypes   []string `yaml:"ignore_fstypes"` // 忽略的文件系统类型
+}
+
+type ProcessCollectorConfig struct {
+	CollectorConfig `yaml:",inline"`
+	Limit           int `yaml:"limit"`
+}
+
+type NetworkCollectorConfig struct {
+	CollectorConfig `yaml:",inline"`
+	Include         string `yaml:"include"` // 网卡名称正则，为空表示全部
+	Exclude         string `yaml:"exclude"` // 排除的网卡名称正则
+}
+
+type SensorCollectorConfig struct {
+	CollectorConfig `yaml:",inline"`
+	SysfsRoot       string `yaml:"sysfs_root"`
+}
+
+type PackageCollectorConfig struct {
+	CollectorConfig `yaml:",inline"`
+	FullInterval    int                    `yaml:"full_interval"` // 定期发送完整列表的间隔（秒）
+	DpkgStatus      string                 `yaml:"dpkg_status"`
+	RPM             *bool                  `yaml:"rpm"`
+	Commands        []VersionCommandConfig `yaml:"commands"`
+}
+
+// UseRPM reports whether rpm -qa should be queried when rpm is installed.
+func (c PackageCollectorConfig) UseRPM() bool {
+	return c.RPM == nil || *c.RPM
+}
+
+type VersionCommandConfig struct {
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"`
+	Pattern string   `yaml:"pattern"`
 }
 
 type LoggingConfig struct {
@@ -44,5 +119,63 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	config.setDefaults()
+
 	return &config, nil
 }
+
+func (c *Config) setDefaults() {
+	if c.Reporting.Interval <= 0 {
+		c.Reporting.Interval = 5
+	}
+	if c.Reporting.FullInterval <= 0 {
+		c.Reporting.FullInterval = 300
+	}
+	if c.Reporting.QueueSize <= 0 {
+		c.Reporting.QueueSize = 100
+	}
+	if c.Health.Listen == "" {
+		c.Health.Listen = "127.0.0.1:9101"
+	}
+
+	col := &c.Collectors
+	if col.Metrics.Interval <= 0 {
+		col.Metrics.Interval = c.Reporting.Interval
+	}
+	if col.Info.Interval <= 0 {
+		col.Info.Interval = 60
+	}
+	if col.Disks.Interval <= 0 {
+		col.Disks.Interval = 60
+	}
+	if col.Disks.IgnoreFSTypes == nil {
+		col.Disks.IgnoreFSTypes = []string{"tmpfs", "devtmpfs", "overlay", "squashfs"}
+	}
+	if col.Processes.Interval <= 0 {
+		col.Processes.Interval = 30
+	}
+	if col.Processes.Limit <= 0 {
+		col.Processes.Limit = 20
+	}
+	if col.Network.Interval <= 0 {
+		col.Network.Interval = c.Reporting.Interval
+	}
+	if col.Inventory.Interval <= 0 {
+		col.Inventory.Interval = 3600
+	}
+	if col.Packages.Interval <= 0 {
+		col.Packages.Interval = 3600
+	}
+	if col.Packages.FullInterval <= 0 {
+		col.Packages.FullInterval = 86400
+	}
+	if col.Packages.DpkgStatus == "" {
+		col.Packages.DpkgStatus = "/var/lib/dpkg/status"
+	}
+	if col.Sensors.Interval <= 0 {
+		col.Sensors.Interval = 30
+	}
+	if col.Network.Exclude == "" {
+		col.Network.Exclude = "^(lo|lo0)$"
+	}
+}
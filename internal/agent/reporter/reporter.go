@@ -3,30 +3,86 @@ package reporter
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/pkg/api"
 )
 
+// ErrQueued is wrapped by the error of a report that failed but is queued
+// for retry, so its content does not need to be collected again.
+var ErrQueued = errors.New("report queued for retry")
+
 type Reporter struct {
-	endpoint string
-	agentKey string
-	client   *http.Client
+	endpoint  string
+	agentKey  string
+	client    *http.Client
+	queue     []*model.AgentReport // 发送失败、等待重试的报告
+	queueSize int
+	resync    bool // 服务端要求重发完整数据
 }
 
-func New(endpoint, agentKey string) *Reporter {
+func New(endpoint, agentKey string, queueSize int) *Reporter {
 	return &Reporter{
-		endpoint: endpoint,
-		agentKey: agentKey,
+		endpoint:  endpoint,
+		agentKey:  agentKey,
+		queueSize: queueSize,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
 }
 
+// QueueDepth returns the number of reports waiting to be retried.
+func (r *Reporter) QueueDepth() int {
+	return len(r.queue)
+}
+
+// Report delivers any queued reports followed by report. If delivery fails,
+// report is queued for the next attempt and the error wraps ErrQueued; the
+// oldest reports are dropped once the queue is full, and the full state is
+// resent after that.
 func (r *Reporter) Report(report *model.AgentReport) error {
+	for len(r.queue) > 0 {
+		if err := r.send(r.queue[0]); err != nil {
+			return r.enqueue(report, err)
+		}
+		r.queue = r.queue[1:]
+	}
+
+	if err := r.send(report); err != nil {
+		return r.enqueue(report, err)
+	}
+
+	return nil
+}
+
+// TakeResync reports whether the server asked for the full state to be
+// resent since the last call, and clears the request.
+func (r *Reporter) TakeResync() bool {
+	resync := r.resync
+	r.resync = false
+	return resync
+}
+
+// enqueue queues report after it failed with err and returns the error to
+// report to the caller.
+func (r *Reporter) enqueue(report *model.AgentReport, err error) error {
+	if r.queueSize <= 0 {
+		return err
+	}
+	if len(r.queue) >= r.queueSize {
+		r.queue = r.queue[1:]
+		r.resync = true // 丢弃的报告中可能有未重复发送的数据
+	}
+	r.queue = append(r.queue, report)
+	return fmt.Errorf("%w: %v", ErrQueued, err)
+}
+
+func (r *Reporter) send(report *model.AgentReport) error {
 	data, err := json.Marshal(report)
 	if err != nil {
 		return fmt.Errorf("failed to marshal report: %w", err)
@@ -50,5 +106,11 @@ func (r *Reporter) Report(report *model.AgentReport) error {
 		return fmt.Errorf("server returned status %d", resp.StatusCode)
 	}
 
+	// 服务端先确认再异步写入，写入失败时在之后的响应中要求重发
+	var ack api.AgentReportResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ack); err == nil && ack.Resync {
+		r.resync = true
+	}
+
 	return nil
 }
@@ -0,0 +1,56 @@
+package reporter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+func TestReportQueue(t *testing.T) {
+	up := false
+	received := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received++
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	// 不缓存时返回原始错误，由调用方重新采集
+	r := New(srv.URL, "key", 0)
+	if err := r.Report(&model.AgentReport{}); err == nil || errors.Is(err, ErrQueued) {
+		t.Fatalf("unqueued failure: err = %v", err)
+	}
+
+	r = New(srv.URL, "key", 2)
+	for i := 0; i < 2; i++ {
+		if err := r.Report(&model.AgentReport{}); !errors.Is(err, ErrQueued) {
+			t.Fatalf("queued failure: err = %v", err)
+		}
+	}
+	if r.TakeResync() {
+		t.Errorf("resync requested before a report was dropped")
+	}
+
+	// 队列已满时丢弃最早的报告，并要求重发完整数据
+	if err := r.Report(&model.AgentReport{}); !errors.Is(err, ErrQueued) {
+		t.Fatalf("queued failure: err = %v", err)
+	}
+	if !r.TakeResync() {
+		t.Errorf("no resync after a report was dropped")
+	}
+
+	up = true
+	if err := r.Report(&model.AgentReport{}); err != nil {
+		t.Fatal(err)
+	}
+	if received != 3 || r.QueueDepth() != 0 {
+		t.Errorf("received %d reports with %d queued, want 3 and 0", received, r.QueueDepth())
+	}
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+// Section is one independently scheduled part of an agent report.
+type Section struct {
+	Name     string
+	Interval time.Duration
+	// Always sends the section whenever it runs, even if unchanged.
+	Always bool
+	// Collect gathers the section data.
+	Collect func() (interface{}, error)
+	// Apply stores collected data in the outgoing report.
+	Apply func(report *model.AgentReport, data interface{})
+	// Sent, if set, is called with the data once the report is delivered.
+	Sent func(data interface{})
+	// Reset, if set, is called when the server lost earlier data and the
+	// section has to start over from its full state.
+	Reset func()
+}
+
+type entry struct {
+	Section
+	lastRun  time.Time
+	lastSent [sha256.Size]byte
+	sent     bool
+}
+
+// Scheduler decides which sections are due on each report tick and skips
+// sections whose content has not changed since it was last delivered.
+type Scheduler struct {
+	entries      []*entry
+	fullInterval time.Duration
+	lastFull     time.Time
+	observer     func(name string, elapsed time.Duration, err error)
+}
+
+// Batch records the sections run for one report and the ones included in
+// it. Call Commit once the report has been delivered so the sections are not
+// run again before their interval and unchanged data is not resent; without
+// Commit they run again on the next report.
+type Batch struct {
+	now     time.Time
+	ran     []*entry
+	entries []*entry
+	hashes  [][sha256.Size]byte
+	data    []interface{}
+}
+
+func New(fullInterval time.Duration) *Scheduler {
+	return &Scheduler{
+		fullInterval: fullInterval,
+		lastFull:     time.Now(),
+	}
+}
+
+func (s *Scheduler) Add(section Section) {
+	s.entries = append(s.entries, &entry{Section: section})
+}
+
+// Observe registers fn to be called after every collector run.
+func (s *Scheduler) Observe(fn func(name string, elapsed time.Duration, err error)) {
+	s.observer = fn
+}
+
+// Names returns the names of the registered sections.
+func (s *Scheduler) Names() []string {
+	names := make([]string, 0, len(s.entries))
+	for _, e := range s.entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// Collect runs every due section and fills report with the ones that changed.
+// A section stays due until the batch is committed.
+func (s *Scheduler) Collect(now time.Time, report *model.AgentReport) *Batch {
+	// 定期强制重发全部数据，保证服务端状态最终一致
+	if s.fullInterval > 0 && now.Sub(s.lastFull) >= s.fullInterval {
+		for _, e := range s.entries {
+			e.sent = false
+		}
+		s.lastFull = now
+	}
+
+	batch := &Batch{now: now}
+	for _, e := range s.entries {
+		if !e.lastRun.IsZero() && now.Sub(e.lastRun) < e.Interval {
+			continue
+		}
+		batch.ran = append(batch.ran, e)
+
+		start := time.Now()
+		data, err := e.Collect()
+		if s.observer != nil {
+			s.observer(e.Name, time.Since(start), err)
+		}
+		if err != nil {
+			log.Printf("Failed to collect %s: %v", e.Name, err)
+			continue
+		}
+
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("Failed to encode %s: %v", e.Name, err)
+			continue
+		}
+		hash := sha256.Sum256(encoded)
+
+		if !e.Always && e.sent && hash == e.lastSent {
+			continue
+		}
+
+		e.Apply(report, data)
+		batch.entries = append(batch.entries, e)
+		batch.hashes = append(batch.hashes, hash)
+		batch.data = append(batch.data, data)
+	}
+
+	return batch
+}
+
+// Resync makes every section resend its data on its next run, for when the
+// server failed to store reports it had already acknowledged.
+func (s *Scheduler) Resync() {
+	for _, e := range s.entries {
+		e.sent = false
+		if e.Reset != nil {
+			e.Reset()
+		}
+	}
+}
+
+func (b *Batch) Commit() {
+	for _, e := range b.ran {
+		e.lastRun = b.now
+	}
+	for i, e := range b.entries {
+		e.lastSent = b.hashes[i]
+		e.sent = true
+		if e.Sent != nil {
+			e.Sent(b.data[i])
+		}
+	}
+}
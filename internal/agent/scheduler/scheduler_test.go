@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+func TestCollectUntilCommitted(t *testing.T) {
+	runs := 0
+	s := New(0)
+	s.Add(Section{
+		Name:     "info",
+		Interval: time.Minute,
+		Collect: func() (interface{}, error) {
+			runs++
+			return "host", nil
+		},
+		Apply: func(r *model.AgentReport, data interface{}) {
+			r.ServerName = data.(string)
+		},
+	})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	report := &model.AgentReport{}
+	s.Collect(now, report)
+	if report.ServerName != "host" {
+		t.Fatalf("section not applied on the first run")
+	}
+
+	// 上一份报告发送失败，下一次仍然采集并发送
+	report = &model.AgentReport{}
+	batch := s.Collect(now.Add(5*time.Second), report)
+	if runs != 2 || report.ServerName != "host" {
+		t.Fatalf("after a failed report: runs = %d, applied = %q; want 2, host", runs, report.ServerName)
+	}
+	batch.Commit()
+
+	// 发送成功后按间隔等待
+	report = &model.AgentReport{}
+	s.Collect(now.Add(10*time.Second), report)
+	if runs != 2 || report.ServerName != "" {
+		t.Errorf("after a delivered report: runs = %d, applied = %q; want 2, none", runs, report.ServerName)
+	}
+	report = &model.AgentReport{}
+	s.Collect(now.Add(5*time.Second+time.Minute), report)
+	if runs != 3 {
+		t.Errorf("runs = %d after the interval, want 3", runs)
+	}
+	if report.ServerName != "" {
+		t.Errorf("unchanged section was resent")
+	}
+}
@@ -0,0 +1,41 @@
+package status
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"time"
+)
+
+// Serve exposes /healthz and /debug/vars for local troubleshooting. Both serve
+// the status cached by the report loop and never probe the process. It blocks
+// until the listener fails.
+func Serve(addr string, t *Tracker, maxAge time.Duration) error {
+	expvar.Publish("agent", expvar.Func(func() interface{} {
+		return t.Snapshot()
+	}))
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		snapshot := t.Snapshot()
+		code := http.StatusOK
+		state := "ok"
+		if !t.Healthy(maxAge) {
+			code = http.StatusServiceUnavailable
+			state = "unhealthy"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":      state,
+			"version":     snapshot.Version,
+			"lastSuccess": snapshot.LastSuccess,
+			"lastError":   snapshot.LastError,
+			"queueDepth":  snapshot.QueueDepth,
+		})
+	})
+
+	return http.ListenAndServe(addr, mux)
+}
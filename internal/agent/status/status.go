@@ -0,0 +1,153 @@
+package status
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Tracker records the agent's own health: collection timings, report
+// outcomes and resource usage.
+type Tracker struct {
+	mu          sync.Mutex
+	version     string
+	startedAt   time.Time
+	proc        *process.Process
+	collectors  map[string]*model.CollectorStatus
+	sent        uint64
+	failed      uint64
+	lastSuccess time.Time
+	lastError   string
+	queueDepth  int
+
+	// 最近一次 Sample 的资源占用
+	rss        uint64
+	cpu        float64
+	goroutines int
+}
+
+func New(version string) *Tracker {
+	t := &Tracker{
+		version:    version,
+		startedAt:  time.Now(),
+		collectors: make(map[string]*model.CollectorStatus),
+	}
+
+	if proc, err := process.NewProcess(int32(os.Getpid())); err == nil {
+		t.proc = proc
+		// 首次调用用于建立 CPU 使用率的基准
+		proc.Percent(0)
+	}
+
+	return t
+}
+
+// RecordCollection records one run of the named collector.
+func (t *Tracker) RecordCollection(name string, elapsed time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cs, ok := t.collectors[name]
+	if !ok {
+		cs = &model.CollectorStatus{Name: name}
+		t.collectors[name] = cs
+	}
+
+	cs.LastRun = time.Now()
+	cs.LastDuration = float64(elapsed) / float64(time.Millisecond)
+	if err != nil {
+		cs.Failures++
+		cs.LastError = err.Error()
+	} else {
+		cs.Successes++
+		cs.LastError = ""
+	}
+}
+
+// RecordReport records the outcome of one report attempt.
+func (t *Tracker) RecordReport(err error, queueDepth int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.queueDepth = queueDepth
+	if err != nil {
+		t.failed++
+		t.lastError = err.Error()
+		return
+	}
+
+	t.sent++
+	t.lastSuccess = time.Now()
+	t.lastError = ""
+}
+
+// Sample measures the agent's own RSS and CPU. CPU usage is averaged since
+// the previous call, so it is sampled once per report cycle rather than on
+// every Snapshot.
+func (t *Tracker) Sample() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.goroutines = runtime.NumGoroutine()
+	if t.proc == nil {
+		return
+	}
+	if memInfo, err := t.proc.MemoryInfo(); err == nil {
+		t.rss = memInfo.RSS
+	}
+	if cpuPercent, err := t.proc.Percent(0); err == nil {
+		t.cpu = cpuPercent
+	}
+}
+
+// Snapshot returns the current status with the resource usage measured by
+// the last Sample.
+func (t *Tracker) Snapshot() *model.AgentStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := &model.AgentStatus{
+		Version:    t.version,
+		StartedAt:  t.startedAt,
+		RSS:        t.rss,
+		CPU:        t.cpu,
+		Goroutines: t.goroutines,
+	}
+
+	status.QueueDepth = t.queueDepth
+	status.ReportsSent = t.sent
+	status.ReportsFailed = t.failed
+	status.LastError = t.lastError
+	if !t.lastSuccess.IsZero() {
+		lastSuccess := t.lastSuccess
+		status.LastSuccess = &lastSuccess
+	}
+
+	status.Collectors = make([]model.CollectorStatus, 0, len(t.collectors))
+	for _, cs := range t.collectors {
+		status.Collectors = append(status.Collectors, *cs)
+	}
+	sort.Slice(status.Collectors, func(i, j int) bool {
+		return status.Collectors[i].Name < status.Collectors[j].Name
+	})
+
+	return status
+}
+
+// Healthy reports whether a report succeeded within maxAge. A freshly started
+// agent is given the same grace period.
+func (t *Tracker) Healthy(maxAge time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last := t.lastSuccess
+	if last.IsZero() {
+		last = t.startedAt
+	}
+	return time.Since(last) <= maxAge
+}
@@ -0,0 +1,301 @@
+// Package anomaly learns per-server, per-metric baselines for every hour of
+// the week from the metrics history and records samples that deviate from
+// them by more than a number of standard deviations. The baselines are
+// learned once and then updated from the samples that are checked.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/maintenance"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/query"
+)
+
+const hoursPerWeek = 7 * 24
+
+// Options configures a Detector.
+type Options struct {
+	Metrics    []string      // 参与检测的指标，见 query.Metrics
+	Sigma      float64       // 偏离多少个标准差视为异常
+	MinSamples int           // 基线桶内样本数少于该值时不检测
+	MinStdDev  float64       // 标准差下限，避免平稳序列的微小波动被判为异常
+	History    time.Duration // 学习基线使用的历史长度
+	Lookback   time.Duration // 每次重新扫描的时间范围，用于检查迟到或补发的样本
+	MergeGap   time.Duration // 间隔不超过该值的异常点合并为一个事件
+	Delay      time.Duration // 只检测早于 now-Delay 的样本，等待批量写入落库
+}
+
+// stat accumulates a mean and variance with Welford's algorithm.
+type stat struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+func (s *stat) add(v float64) {
+	s.n++
+	d := v - s.mean
+	s.mean += d / float64(s.n)
+	s.m2 += d * (v - s.mean)
+}
+
+// merge combines o into s as if its samples had been added to s.
+func (s *stat) merge(o stat) {
+	if o.n == 0 {
+		return
+	}
+	n := s.n + o.n
+	d := o.mean - s.mean
+	s.mean += d * float64(o.n) / float64(n)
+	s.m2 += o.m2 + d*d*float64(s.n)*float64(o.n)/float64(n)
+	s.n = n
+}
+
+func (s *stat) stddev() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.n-1))
+}
+
+// hourStat is the stat of the samples within one hour.
+type hourStat struct {
+	hour int64 // Unix 时间的小时数
+	stat
+}
+
+// Baseline holds, for every hour of the week (UTC, Monday 00:00 = 0), one
+// stat per hour of history falling on it, so that hours leaving the history
+// are dropped without reading their samples again.
+type Baseline [hoursPerWeek][]hourStat
+
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	day := (int(t.Weekday()) + 6) % 7 // 周一为 0
+	return day*24 + t.Hour()
+}
+
+func (b *Baseline) add(t time.Time, v float64) {
+	slot := &b[hourOfWeek(t)]
+	hour := t.Unix() / 3600
+	for i := len(*slot) - 1; i >= 0; i-- {
+		if (*slot)[i].hour == hour {
+			(*slot)[i].add(v)
+			return
+		}
+	}
+	h := hourStat{hour: hour}
+	h.add(v)
+	*slot = append(*slot, h)
+}
+
+// at returns the combined stat for the hour of the week of t from the
+// previous weeks. The current week is left out so that a sample is not
+// compared with the minutes just before it.
+func (b *Baseline) at(t time.Time) stat {
+	var s stat
+	last := t.Unix()/3600 - hoursPerWeek
+	for _, h := range b[hourOfWeek(t)] {
+		if h.hour <= last {
+			s.merge(h.stat)
+		}
+	}
+	return s
+}
+
+// expire drops the hours before the given one and reports whether the
+// baseline is empty afterwards.
+func (b *Baseline) expire(before int64) bool {
+	empty := true
+	for i := range b {
+		kept := b[i][:0]
+		for _, h := range b[i] {
+			if h.hour >= before {
+				kept = append(kept, h)
+			}
+		}
+		b[i] = kept
+		empty = empty && len(kept) == 0
+	}
+	return empty
+}
+
+// Detector finds anomalies in new samples. Run is called periodically.
+type Detector struct {
+	db   database.Store
+	opts Options
+
+	mu        sync.Mutex
+	baselines map[string]map[string]*Baseline // server -> metric
+	checked   map[string]time.Time            // server -> 已检查的最新样本时间
+	expired   int64                           // 上次清理过期基线时的小时数
+	open      map[string]*model.Anomaly       // server/metric -> 最近一次异常事件
+}
+
+// New validates opts and returns a Detector. Samples already stored when
+// it first runs are learned, not checked.
+func New(db database.Store, opts Options) (*Detector, error) {
+	if len(opts.Metrics) == 0 {
+		return nil, fmt.Errorf("no metrics configured")
+	}
+	for _, metric := range opts.Metrics {
+		if _, ok := query.Metrics[metric]; !ok {
+			return nil, fmt.Errorf("unknown metric %q", metric)
+		}
+	}
+	if opts.Sigma <= 0 {
+		return nil, fmt.Errorf("sigma must be positive")
+	}
+
+	return &Detector{
+		db:      db,
+		opts:    opts,
+		checked: make(map[string]time.Time),
+		open:    make(map[string]*model.Anomaly),
+	}, nil
+}
+
+// Run learns the baselines on the first call. After that it checks the
+// samples of the last Lookback that have not been checked yet, adds them to
+// the baselines and returns how many new anomalies were recorded.
+func (d *Detector) Run(now time.Time) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	end := now.Add(-d.opts.Delay)
+	if d.baselines == nil {
+		if err := d.learn(now.Add(-d.opts.History), end); err != nil {
+			return 0, fmt.Errorf("learn baselines: %w", err)
+		}
+	}
+
+	created, err := d.detect(end.Add(-d.opts.Lookback), end)
+	if err != nil {
+		return created, err
+	}
+	d.expire(now.Add(-d.opts.History))
+	return created, nil
+}
+
+func (d *Detector) learn(start, end time.Time) error {
+	d.baselines = make(map[string]map[string]*Baseline)
+	return d.db.EachMetric(nil, start, end, func(m *model.Metrics) error {
+		d.checked[m.ServerID] = m.Timestamp
+		for _, metric := range d.opts.Metrics {
+			d.baseline(m.ServerID, metric).add(m.Timestamp, query.Metrics[metric](m))
+		}
+		return nil
+	})
+}
+
+func (d *Detector) baseline(serverID, metric string) *Baseline {
+	byMetric := d.baselines[serverID]
+	if byMetric == nil {
+		byMetric = make(map[string]*Baseline)
+		d.baselines[serverID] = byMetric
+	}
+	b := byMetric[metric]
+	if b == nil {
+		b = &Baseline{}
+		byMetric[metric] = b
+	}
+	return b
+}
+
+// expire drops what is older than the history once per hour.
+func (d *Detector) expire(before time.Time) {
+	hour := before.Unix() / 3600
+	if hour == d.expired {
+		return
+	}
+	d.expired = hour
+
+	for serverID, byMetric := range d.baselines {
+		for metric, b := range byMetric {
+			if b.expire(hour) {
+				delete(byMetric, metric)
+			}
+		}
+		if len(byMetric) == 0 {
+			delete(d.baselines, serverID)
+		}
+	}
+	for serverID, t := range d.checked {
+		if t.Before(before) {
+			delete(d.checked, serverID)
+		}
+	}
+	for key, a := range d.open {
+		if a.EndedAt.Before(before) {
+			delete(d.open, key)
+		}
+	}
+}
+
+func (d *Detector) detect(start, end time.Time) (int, error) {
+	// 维护窗口内的服务器不做检测
+	skip := make(map[string]bool)
+	inMaintenance, err := maintenance.ActiveServers(d.db, end)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range inMaintenance {
+		skip[id] = true
+	}
+
+	dirty := make(map[*model.Anomaly]bool)
+	created := 0
+	err = d.db.EachMetric(nil, start, end, func(m *model.Metrics) error {
+		// 样本按服务器、时间排序；每个服务器只检查比上次更新的样本
+		if !m.Timestamp.After(d.checked[m.ServerID]) {
+			return nil
+		}
+		d.checked[m.ServerID] = m.Timestamp
+
+		for _, metric := range d.opts.Metrics {
+			b := d.baseline(m.ServerID, metric)
+			value := query.Metrics[metric](m)
+			s := b.at(m.Timestamp)
+			b.add(m.Timestamp, value)
+			if skip[m.ServerID] || s.n < d.opts.MinSamples {
+				continue
+			}
+			stddev := math.Max(s.stddev(), d.opts.MinStdDev)
+			score := (value - s.mean) / stddev
+			if math.Abs(score) < d.opts.Sigma {
+				continue
+			}
+
+			key := m.ServerID + "/" + metric
+			a := d.open[key]
+			if a == nil || m.Timestamp.Sub(a.EndedAt) > d.opts.MergeGap {
+				a = &model.Anomaly{ServerID: m.ServerID, Metric: metric, StartedAt: m.Timestamp}
+				d.open[key] = a
+				created++
+			}
+			a.EndedAt = m.Timestamp
+			a.Points++
+			if a.Points == 1 || math.Abs(score) > math.Abs(a.Score) {
+				a.Value, a.Expected, a.StdDev, a.Score = value, s.mean, stddev, score
+			}
+			dirty[a] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// 读完游标后再写入，避免读写交错
+	for a := range dirty {
+		if err := d.db.SaveAnomaly(a); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,140 @@
+package anomaly
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/model"
+)
+
+func testOptions() Options {
+	return Options{
+		Metrics:    []string{"cpu"},
+		Sigma:      3,
+		MinSamples: 3,
+		MinStdDev:  1,
+		History:    14 * 24 * time.Hour,
+		Lookback:   15 * time.Minute,
+		MergeGap:   5 * time.Minute,
+		Delay:      30 * time.Second,
+	}
+}
+
+// seed stores two weeks of a flat CPU series, one sample every 10 minutes.
+func seed(t *testing.T, db database.Store, now time.Time) {
+	t.Helper()
+	for ts := now.Add(-14 * 24 * time.Hour); ts.Before(now.Add(-time.Hour)); ts = ts.Add(10 * time.Minute) {
+		cpu := 10 + float64(ts.Minute()%3) // 10、11、12 交替
+		if err := db.InsertMetrics(&model.Metrics{ServerID: "a", Timestamp: ts, CPU: cpu}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectLateSample(t *testing.T) {
+	db := database.NewMemory()
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	seed(t, db, now)
+
+	d, err := New(db, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.Run(now); err != nil || n != 0 {
+		t.Fatalf("first run = %d, %v; want 0, nil", n, err)
+	}
+
+	// 样本在上次检测之后才写入，但时间戳早于上次检测的截止时间
+	late := &model.Metrics{ServerID: "a", Timestamp: now.Add(-2 * time.Minute), CPU: 95}
+	if err := db.InsertMetrics(late); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.Run(now.Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("run after late sample = %d, %v; want 1, nil", n, err)
+	}
+
+	// 再次扫描同一范围不会重复记录
+	if n, err := d.Run(now.Add(2 * time.Minute)); err != nil || n != 0 {
+		t.Fatalf("rescan = %d, %v; want 0, nil", n, err)
+	}
+	anomalies, err := db.GetAnomalies("a", now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anomalies) != 1 || anomalies[0].Points != 1 || anomalies[0].Value != 95 {
+		t.Errorf("anomalies = %+v, want one point at 95", anomalies)
+	}
+}
+
+func TestBaselineIncremental(t *testing.T) {
+	db := database.NewMemory()
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	seed(t, db, now)
+
+	d, err := New(db, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Run(now.Add(-2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// 之后的样本逐次检测并加入基线
+	for ts := now.Add(-2*time.Hour + 10*time.Minute); !ts.After(now); ts = ts.Add(10 * time.Minute) {
+		if _, err := d.Run(ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 增量更新的基线与一次完整学习的结果一致
+	full, err := New(db, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := full.Run(now); err != nil {
+		t.Fatal(err)
+	}
+	for hour := 0; hour < hoursPerWeek; hour++ {
+		ts := now.Add(time.Duration(hour) * time.Hour)
+		got := d.baselines["a"]["cpu"].at(ts)
+		want := full.baselines["a"]["cpu"].at(ts)
+		if got.n != want.n || math.Abs(got.mean-want.mean) > 1e-9 || math.Abs(got.stddev()-want.stddev()) > 1e-9 {
+			t.Fatalf("hour %d: baseline %+v, want %+v", hour, got, want)
+		}
+	}
+}
+
+func TestDetectSustained(t *testing.T) {
+	db := database.NewMemory()
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	seed(t, db, now)
+
+	d, err := New(db, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Run(now); err != nil {
+		t.Fatal(err)
+	}
+
+	// 当前小时内持续偏高，超过 30 个样本后仍与往周同一时段比较
+	const points = 60
+	for i := 0; i < points; i++ {
+		ts := now.Add(time.Duration(i) * 10 * time.Second)
+		if err := db.InsertMetrics(&model.Metrics{ServerID: "a", Timestamp: ts, CPU: 95}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.Run(ts.Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	anomalies, err := db.GetAnomalies("a", now, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anomalies) != 1 || anomalies[0].Points != points {
+		t.Errorf("anomalies = %+v, want one event of %d points", anomalies, points)
+	}
+}
@@ -0,0 +1,204 @@
+// Package backup takes, rotates and restores snapshots of the SQLite
+// database.
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/pkg/api"
+)
+
+const (
+	filePrefix = "monitor-"
+	fileSuffix = ".db"
+	timeFormat = "20060102T150405Z"
+)
+
+// Info describes one backup file.
+type Info = api.Backup
+
+// Manager writes backups into a directory and keeps the newest Keep of them.
+type Manager struct {
+	db   database.Backuper
+	dir  string
+	keep int
+	mu   sync.Mutex
+}
+
+// New returns a Manager; keep <= 0 disables rotation.
+func New(db database.Backuper, dir string, keep int) *Manager {
+	return &Manager{db: db, dir: dir, keep: keep}
+}
+
+// Create takes a snapshot and removes backups beyond the retention count.
+func (m *Manager) Create() (Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return Info{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	name := filePrefix + now.Format(timeFormat) + fileSuffix
+	path := filepath.Join(m.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return Info{}, fmt.Errorf("backup %s already exists", name)
+	}
+
+	// 先写临时文件，完成后再改名，避免留下不完整的备份
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := m.db.Backup(tmp); err != nil {
+		os.Remove(tmp)
+		return Info{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Info{}, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	info := Info{Name: name, Size: stat.Size(), CreatedAt: now}
+
+	if err := m.rotate(); err != nil {
+		return info, fmt.Errorf("backup created but rotation failed: %w", err)
+	}
+	return info, nil
+}
+
+func (m *Manager) rotate() error {
+	if m.keep <= 0 {
+		return nil
+	}
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(m.keep, len(backups)):] {
+		if err := os.Remove(filepath.Join(m.dir, b.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the backups in the directory, newest first.
+func (m *Manager) List() ([]Info, error) {
+	entries, err := os.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Info{}
+	for _, e := range entries {
+		created, ok := parseName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		stat, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Info{Name: e.Name(), Size: stat.Size(), CreatedAt: created})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Path returns the file path of the named backup. Only names produced by
+// Create are accepted, so callers cannot escape the backup directory.
+func (m *Manager) Path(name string) (string, error) {
+	if _, ok := parseName(name); !ok {
+		return "", fmt.Errorf("invalid backup name %q", name)
+	}
+	path := filepath.Join(m.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("backup %q not found", name)
+	}
+	return path, nil
+}
+
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return time.Time{}, false
+	}
+	ts := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+	t, err := time.Parse(timeFormat, ts)
+	return t, err == nil
+}
+
+// Restore replaces the database at dbPath with the backup at src after
+// checking its integrity and schema version. The current database is kept
+// next to it with a ".pre-restore-<time>" suffix, whose path is returned.
+// The server must not be running: Restore refuses while the database's -wal
+// or -shm file exists, which SQLite keeps while a connection is open.
+func Restore(src, dbPath string) (previous string, err error) {
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(dbPath + suffix); err == nil {
+			return "", fmt.Errorf("%s exists: stop the server first; if it is not running, "+
+				"start and stop it once so SQLite checkpoints the WAL", dbPath+suffix)
+		}
+	}
+	if _, err := database.VerifySQLiteFile(src); err != nil {
+		return "", fmt.Errorf("invalid backup: %w", err)
+	}
+
+	// 先复制到目标目录，再原子替换
+	tmp := dbPath + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		previous = dbPath + ".pre-restore-" + time.Now().UTC().Format(timeFormat)
+		if err := os.Rename(dbPath, previous); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return previous, err
+	}
+	return previous, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -1,17 +1,27 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Auth     AuthConfig     `yaml:"auth"`
-	Data     DataConfig     `yaml:"data"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Data      DataConfig      `yaml:"data"`
+	Ingest    IngestConfig    `yaml:"ingest"`
+	Backup    BackupConfig    `yaml:"backup"`
+	Retention RetentionConfig `yaml:"retention"`
+	Lifecycle LifecycleConfig `yaml:"lifecycle"`
+	Anomaly   AnomalyConfig   `yaml:"anomaly"`
+	SLO       SLOConfig       `yaml:"slo"`
+	Report    ReportConfig    `yaml:"report"`
+	Logging   LoggingConfig   `yaml:"logging"`
 }
 
 type ServerConfig struct {
@@ -20,17 +30,150 @@ type ServerConfig struct {
 }
 
 type DatabaseConfig struct {
-	Path string `yaml:"path"`
+	Driver string       `yaml:"driver"` // sqlite（默认）、postgres 或 memory
+	Path   string       `yaml:"path"`
+	DSN    string       `yaml:"dsn"`
+	SQLite SQLiteConfig `yaml:"sqlite"`
+}
+
+// SQLiteConfig tunes the SQLite connection for concurrent writers.
+type SQLiteConfig struct {
+	JournalMode string `yaml:"journal_mode"` // 默认 WAL，读写互不阻塞
+	BusyTimeout int    `yaml:"busy_timeout"` // 锁等待时间（毫秒），默认 5000
+	Synchronous string `yaml:"synchronous"`  // 默认 NORMAL，WAL 模式下足够安全
+}
+
+// IsSQLite reports whether the SQLite driver (the default) is configured.
+func (d DatabaseConfig) IsSQLite() bool {
+	return d.Driver == "" || d.Driver == "sqlite" || d.Driver == "sqlite3"
+}
+
+// Source returns the connection string for the configured driver.
+func (d DatabaseConfig) Source() string {
+	if !d.IsSQLite() {
+		return d.DSN
+	}
+	if strings.Contains(d.Path, "?") {
+		return d.Path // 已自带连接参数
+	}
+
+	params := url.Values{}
+	params.Set("_journal_mode", d.SQLite.JournalMode)
+	params.Set("_busy_timeout", fmt.Sprint(d.SQLite.BusyTimeout))
+	params.Set("_synchronous", d.SQLite.Synchronous)
+	// 事务开始即获取写锁，避免读后写升级锁时出现 SQLITE_BUSY
+	params.Set("_txlock", "immediate")
+	return d.Path + "?" + params.Encode()
+}
+
+// File returns the SQLite database file path without connection parameters.
+func (d DatabaseConfig) File() string {
+	path, _, _ := strings.Cut(d.Path, "?")
+	return strings.TrimPrefix(path, "file:")
 }
 
 type AuthConfig struct {
 	APIKey   string `yaml:"api_key"`
 	AgentKey string `yaml:"agent_key"`
+	AdminKey string `yaml:"admin_key"` // 管理接口（备份、清理）的密钥，为空时关闭管理接口
 }
 
 type DataConfig struct {
-	RetentionDays   int `yaml:"retention_days"`
-	CleanupInterval int `yaml:"cleanup_interval"`
+	RetentionDays   int `yaml:"retention_days"`   // metrics 和 sensors 的默认保留天数
+	CleanupInterval int `yaml:"cleanup_interval"` // 清理间隔（小时）
+}
+
+// RetentionConfig sets how long each kind of data is kept, in days. Kinds
+// missing from Days are kept forever, except metrics and sensors which
+// default to data.retention_days.
+type RetentionConfig struct {
+	Days      map[string]int      `yaml:"days"`
+	Overrides []RetentionOverride `yaml:"overrides"`
+	Vacuum    string              `yaml:"vacuum"`          // incremental（默认）、full 或 off
+	Interval  int                 `yaml:"vacuum_interval"` // VACUUM 间隔（小时），默认 168
+}
+
+// RetentionOverride changes the retention of some kinds for the servers it
+// selects, by ID or label selector. The first matching override wins.
+type RetentionOverride struct {
+	Servers  []string       `yaml:"servers"`
+	Selector string         `yaml:"selector"`
+	Days     map[string]int `yaml:"days"`
+}
+
+// IngestConfig controls how agent reports are queued and written in batches.
+type IngestConfig struct {
+	QueueSize     int `yaml:"queue_size"`     // 队列容量，满时返回 503
+	BatchSize     int `yaml:"batch_size"`     // 单个事务写入的最大报告数
+	FlushInterval int `yaml:"flush_interval"` // 最长等待时间（毫秒）
+}
+
+// LifecycleConfig controls automatic archival of servers that stopped
+// reporting.
+type LifecycleConfig struct {
+	ArchiveAfter int `yaml:"archive_after"` // 离线多少小时后自动归档，0 表示不归档
+}
+
+// AnomalyConfig controls detection of samples that deviate from each
+// server's hour-of-week baseline.
+type AnomalyConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Metrics     []string `yaml:"metrics"`      // 默认 cpu、memory
+	Sigma       float64  `yaml:"sigma"`        // 默认 3
+	MinSamples  int      `yaml:"min_samples"`  // 默认 30
+	MinStdDev   float64  `yaml:"min_stddev"`   // 默认 1
+	HistoryDays int      `yaml:"history_days"` // 默认 28
+	Interval    int      `yaml:"interval"`     // 检测间隔（秒），默认 60
+	Lookback    int      `yaml:"lookback"`     // 重新检查迟到样本的时间范围（分钟），默认 15
+}
+
+// SLOConfig lists the service level objectives reported per server.
+type SLOConfig struct {
+	Slot       int            `yaml:"slot"` // 可用性统计粒度（秒），默认 60
+	Objectives []SLOObjective `yaml:"objectives"`
+}
+
+// SLOObjective is one target, applied to the servers it selects (all
+// servers when neither Servers nor Selector is set).
+type SLOObjective struct {
+	Name       string   `yaml:"name"`
+	Type       string   `yaml:"type"`       // availability 或 metric
+	Target     float64  `yaml:"target"`     // 目标百分比，如 99.9
+	Metric     string   `yaml:"metric"`     // type 为 metric 时的指标
+	Threshold  float64  `yaml:"threshold"`  // 指标阈值
+	Comparison string   `yaml:"comparison"` // below（默认）或 above，满足时样本计为达标
+	Servers    []string `yaml:"servers"`
+	Selector   string   `yaml:"selector"`
+}
+
+// ReportConfig controls the HTML summary reports.
+type ReportConfig struct {
+	Dir                string     `yaml:"dir"`                 // 报告目录，默认 ./data/reports
+	Keep               int        `yaml:"keep"`                // 保留的报告数量，默认 60
+	Daily              bool       `yaml:"daily"`               // 每天生成前一天的日报
+	Weekly             bool       `yaml:"weekly"`              // 每周一生成上一周的周报
+	TopN               int        `yaml:"top_n"`               // 资源占用排行数量，默认 5
+	DiskThreshold      float64    `yaml:"disk_threshold"`      // 磁盘使用率告警线（%），默认 85
+	AvailabilityTarget float64    `yaml:"availability_target"` // 可用性目标（%），默认 99.9
+	SMTP               SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig is the mail server used to send reports; mail is disabled
+// while Host is empty.
+type SMTPConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"` // 默认 25
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// BackupConfig controls scheduled SQLite snapshots.
+type BackupConfig struct {
+	Dir      string `yaml:"dir"`      // 备份目录，默认 ./data/backups
+	Interval int    `yaml:"interval"` // 自动备份间隔（小时），0 表示关闭
+	Keep     int    `yaml:"keep"`     // 保留的备份数量，默认 7
 }
 
 type LoggingConfig struct {
@@ -49,5 +192,95 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	config.setDefaults()
 	return &config, nil
 }
+
+func (c *Config) setDefaults() {
+	if c.Database.SQLite.JournalMode == "" {
+		c.Database.SQLite.JournalMode = "WAL"
+	}
+	if c.Database.SQLite.BusyTimeout <= 0 {
+		c.Database.SQLite.BusyTimeout = 5000
+	}
+	if c.Database.SQLite.Synchronous == "" {
+		c.Database.SQLite.Synchronous = "NORMAL"
+	}
+	if c.Ingest.QueueSize <= 0 {
+		c.Ingest.QueueSize = 10000
+	}
+	if c.Ingest.BatchSize <= 0 {
+		c.Ingest.BatchSize = 200
+	}
+	if c.Ingest.FlushInterval <= 0 {
+		c.Ingest.FlushInterval = 500
+	}
+	if c.Data.RetentionDays <= 0 {
+		c.Data.RetentionDays = 30
+	}
+	if c.Data.CleanupInterval <= 0 {
+		c.Data.CleanupInterval = 24
+	}
+	if c.Retention.Days == nil {
+		c.Retention.Days = map[string]int{}
+	}
+	for _, kind := range []string{"metrics", "sensors"} {
+		if _, ok := c.Retention.Days[kind]; !ok {
+			c.Retention.Days[kind] = c.Data.RetentionDays
+		}
+	}
+	if c.Retention.Vacuum == "" {
+		c.Retention.Vacuum = "incremental"
+	}
+	if c.Retention.Interval <= 0 {
+		c.Retention.Interval = 168
+	}
+	if len(c.Anomaly.Metrics) == 0 {
+		c.Anomaly.Metrics = []string{"cpu", "memory"}
+	}
+	if c.Anomaly.Sigma <= 0 {
+		c.Anomaly.Sigma = 3
+	}
+	if c.Anomaly.MinSamples <= 0 {
+		c.Anomaly.MinSamples = 30
+	}
+	if c.Anomaly.MinStdDev <= 0 {
+		c.Anomaly.MinStdDev = 1
+	}
+	if c.Anomaly.HistoryDays <= 0 {
+		c.Anomaly.HistoryDays = 28
+	}
+	if c.Anomaly.Interval <= 0 {
+		c.Anomaly.Interval = 60
+	}
+	if c.Anomaly.Lookback <= 0 {
+		c.Anomaly.Lookback = 15
+	}
+	if c.SLO.Slot <= 0 {
+		c.SLO.Slot = 60
+	}
+	if c.Report.Dir == "" {
+		c.Report.Dir = "./data/reports"
+	}
+	if c.Report.Keep <= 0 {
+		c.Report.Keep = 60
+	}
+	if c.Report.TopN <= 0 {
+		c.Report.TopN = 5
+	}
+	if c.Report.DiskThreshold <= 0 {
+		c.Report.DiskThreshold = 85
+	}
+	if c.Report.AvailabilityTarget <= 0 {
+		c.Report.AvailabilityTarget = 99.9
+	}
+	if c.Report.SMTP.Port <= 0 {
+		c.Report.SMTP.Port = 25
+	}
+	if c.Backup.Dir == "" {
+		c.Backup.Dir = "./data/backups"
+	}
+	if c.Backup.Keep <= 0 {
+		c.Backup.Keep = 7
+	}
+}
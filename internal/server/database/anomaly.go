@@ -0,0 +1,48 @@
+package database
+
+import (
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+// SaveAnomaly inserts a new anomaly (ID 0, the ID is set) or updates an
+// existing one that has grown.
+func (db *DB) SaveAnomaly(a *model.Anomaly) error {
+	if a.ID == 0 {
+		return db.QueryRow(`INSERT INTO anomalies
+			(server_id, metric, started_at, ended_at, value, expected, stddev, score, points)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+			a.ServerID, a.Metric, a.StartedAt, a.EndedAt, a.Value, a.Expected, a.StdDev, a.Score, a.Points,
+		).Scan(&a.ID)
+	}
+
+	_, err := db.Exec(`UPDATE anomalies SET ended_at = ?, value = ?, expected = ?, stddev = ?, score = ?, points = ?
+	                   WHERE id = ?`,
+		a.EndedAt, a.Value, a.Expected, a.StdDev, a.Score, a.Points, a.ID)
+	return err
+}
+
+// GetAnomalies returns the anomalies of a server overlapping [start, end),
+// newest first.
+func (db *DB) GetAnomalies(serverID string, start, end time.Time) ([]model.Anomaly, error) {
+	rows, err := db.Query(`SELECT id, server_id, metric, started_at, ended_at, value, expected, stddev, score, points
+	                       FROM anomalies WHERE server_id = ? AND started_at < ? AND ended_at >= ?
+	                       ORDER BY started_at DESC`, serverID, end, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := []model.Anomaly{}
+	for rows.Next() {
+		var a model.Anomaly
+		err := rows.Scan(&a.ID, &a.ServerID, &a.Metric, &a.StartedAt, &a.EndedAt,
+			&a.Value, &a.Expected, &a.StdDev, &a.Score, &a.Points)
+		if err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// Backuper is implemented by stores that can take a consistent snapshot
+// while the server is running.
+type Backuper interface {
+	Backup(path string) error
+}
+
+var _ Backuper = (*DB)(nil)
+
+// Backup writes a consistent copy of the database to path, which must not
+// exist yet. Writers are only blocked while the copy is taken.
+func (db *DB) Backup(path string) error {
+	if db.isPostgres() {
+		return errors.New("online backup is only supported for sqlite, use pg_dump for postgres")
+	}
+	_, err := db.Exec(`VACUUM INTO ?`, path)
+	return err
+}
+
+// LatestSchemaVersion is the version of the newest known migration.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// VerifySQLiteFile checks that path is an intact SQLite monitor database
+// this build can run, and returns its schema version. Older versions are
+// accepted since they are migrated on start-up.
+func VerifySQLiteFile(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+
+	// 路径需要转义，否则其中的 ? 或 # 会被当作 URI 参数
+	conn, err := sql.Open("sqlite3", "file:"+url.PathEscape(path)+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return 0, fmt.Errorf("not a valid sqlite database: %w", err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var version sql.NullInt64
+	if err := conn.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("not a monitor database (no schema_migrations): %w", err)
+	}
+	if !version.Valid {
+		return 0, errors.New("not a monitor database (no migrations applied)")
+	}
+	if int(version.Int64) > LatestSchemaVersion() {
+		return 0, fmt.Errorf("schema version %d is newer than this server supports (%d)",
+			version.Int64, LatestSchemaVersion())
+	}
+
+	return int(version.Int64), nil
+}
@@ -3,18 +3,21 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/monitor-system/internal/server/model"
 )
 
+// DB is the SQL implementation of Store, shared by the SQLite and PostgreSQL
+// backends. Queries use "?" placeholders and are rebound for the driver.
 type DB struct {
 	*sql.DB
+	driver string
 }
 
-func New(dbPath string) (*DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+func open(driver, source string) (*DB, error) {
+	db, err := sql.Open(driver, source)
 	if err != nil {
 		return nil, err
 	}
@@ -23,97 +26,155 @@ func New(dbPath string) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, driver: driver}, nil
 }
 
-func (db *DB) Initialize() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS servers (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		ip TEXT NOT NULL,
-		os TEXT,
-		location TEXT,
-		status TEXT DEFAULT 'offline',
-		last_heartbeat DATETIME,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS metrics (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		server_id TEXT NOT NULL,
-		timestamp DATETIME NOT NULL,
-		cpu REAL,
-		memory REAL,
-		disk_read REAL,
-		disk_write REAL,
-		network_in REAL,
-		network_out REAL,
-		FOREIGN KEY (server_id) REFERENCES servers(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_metrics_server_time ON metrics(server_id, timestamp DESC);
-
-	CREATE TABLE IF NOT EXISTS server_info (
-		server_id TEXT PRIMARY KEY,
-		cpu_cores INTEGER,
-		total_memory INTEGER,
-		used_memory INTEGER,
-		uptime INTEGER,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (server_id) REFERENCES servers(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS disks (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		server_id TEXT NOT NULL,
-		name TEXT,
-		mount_point TEXT,
-		fs_type TEXT,
-		total_size INTEGER,
-		used_size INTEGER,
-		available_size INTEGER,
-		usage_percent REAL,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (server_id) REFERENCES servers(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS processes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		server_id TEXT NOT NULL,
-		pid INTEGER,
-		name TEXT,
-		cpu REAL,
-		memory REAL,
-		username TEXT,
-		status TEXT,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (server_id) REFERENCES servers(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_processes_server ON processes(server_id);
-
-	CREATE TABLE IF NOT EXISTS network_interfaces (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		server_id TEXT NOT NULL,
-		name TEXT,
-		type TEXT,
-		upload_speed REAL,
-		download_speed REAL,
-		total_upload INTEGER,
-		total_download INTEGER,
-		status TEXT,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (server_id) REFERENCES servers(id)
-	);
-	`
+func (db *DB) isPostgres() bool {
+	return db.driver == "postgres"
+}
+
+// rebind converts "?" placeholders to the driver's syntax. A "?" inside a
+// quoted string or identifier is left alone; comments are not recognized, so
+// queries must not put "?" in one.
+func (db *DB) rebind(query string) string {
+	if !db.isPostgres() {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	var quote byte // 当前所在引号，0 表示不在引号内
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			// 引号内的 '' 或 "" 是转义，按两次切换处理即可
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.rebind(query), utcArgs(args)...)
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.rebind(query), utcArgs(args)...)
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.rebind(query), utcArgs(args)...)
+}
+
+// Tx wraps sql.Tx so statements inside transactions are rebound too.
+type Tx struct {
+	*sql.Tx
+	db *DB
+}
+
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, db: db}, nil
+}
+
+// inTx runs fn in a transaction, committing if it returns nil.
+func (db *DB) inTx(fn func(tx *Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.db.rebind(query), utcArgs(args)...)
+}
+
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.db.rebind(query), utcArgs(args)...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.db.rebind(query), utcArgs(args)...)
+}
+
+func (tx *Tx) Prepare(query string) (*Stmt, error) {
+	stmt, err := tx.Tx.Prepare(tx.db.rebind(query))
+	if err != nil {
+		return nil, err
+	}
+	return &Stmt{Stmt: stmt}, nil
+}
+
+// Stmt wraps sql.Stmt so prepared statements normalize timestamps too.
+type Stmt struct {
+	*sql.Stmt
+}
+
+func (s *Stmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.Stmt.Exec(utcArgs(args)...)
+}
 
-	_, err := db.Exec(schema)
+// utcArgs converts time arguments to UTC. SQLite stores times as text with
+// the value's zone offset and compares them as strings, so mixing zones
+// breaks range filters; PostgreSQL is unaffected either way.
+func utcArgs(args []interface{}) []interface{} {
+	out := args
+	for i, arg := range args {
+		var t time.Time
+		switch v := arg.(type) {
+		case time.Time:
+			t = v
+		case *time.Time:
+			if v == nil {
+				continue
+			}
+			t = *v
+		default:
+			continue
+		}
+		// 调用方可能传入自己的切片，复制后再改
+		if &out[0] == &args[0] {
+			out = append([]interface{}(nil), args...)
+		}
+		out[i] = t.UTC()
+	}
+	return out
+}
+
+// Initialize brings the schema up to date by applying pending migrations.
+func (db *DB) Initialize() error {
+	_, err := db.Migrate()
 	return err
 }
 
 func (db *DB) UpsertServer(server *model.Server) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.upsertServer(server)
+	})
+}
+
+// upsertServer keeps the maintenance status of an existing server; only
+// UpdateServerStatus sets and clears it.
+func (tx *Tx) upsertServer(server *model.Server) error {
 	query := `
 	INSERT INTO servers (id, name, ip, os, location, status, last_heartbeat, updated_at)
 	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
@@ -122,18 +183,20 @@ func (db *DB) UpsertServer(server *model.Server) error {
 		ip = excluded.ip,
 		os = excluded.os,
 		location = excluded.location,
-		status = excluded.status,
+		status = CASE WHEN servers.lifecycle = 'maintenance' OR servers.status = 'maintenance'
+			THEN servers.status ELSE excluded.status END,
 		last_heartbeat = excluded.last_heartbeat,
-		updated_at = excluded.updated_at
+		updated_at = excluded.updated_at,
+		lifecycle = CASE WHEN servers.lifecycle = 'archived' THEN 'active' ELSE servers.lifecycle END
 	`
 
-	_, err := db.Exec(query, server.ID, server.Name, server.IP, server.OS,
+	_, err := tx.Exec(query, server.ID, server.Name, server.IP, server.OS,
 		server.Location, server.Status, server.LastHeartbeat, time.Now())
 	return err
 }
 
 func (db *DB) GetServers() ([]model.Server, error) {
-	query := `SELECT id, name, ip, status, os, location, last_heartbeat, created_at, updated_at FROM servers ORDER BY name`
+	query := `SELECT id, name, ip, status, lifecycle, os, location, last_heartbeat, created_at, updated_at FROM servers ORDER BY name`
 
 	rows, err := db.Query(query)
 	if err != nil {
@@ -144,29 +207,257 @@ func (db *DB) GetServers() ([]model.Server, error) {
 	var servers []model.Server
 	for rows.Next() {
 		var s model.Server
-		err := rows.Scan(&s.ID, &s.Name, &s.IP, &s.Status, &s.OS, &s.Location,
+		err := rows.Scan(&s.ID, &s.Name, &s.IP, &s.Status, &s.Lifecycle, &s.OS, &s.Location,
 			&s.LastHeartbeat, &s.CreatedAt, &s.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 		servers = append(servers, s)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	labels, err := db.getAllLabels()
+	if err != nil {
+		return nil, err
+	}
+	for i := range servers {
+		servers[i].Labels = labels[servers[i].ID]
+	}
 
 	return servers, nil
 }
 
+// getAllLabels returns the labels of every server keyed by server ID.
+func (db *DB) getAllLabels() (map[string]map[string]string, error) {
+	return scanLabels(db.Query(`SELECT server_id, key, value FROM server_labels`))
+}
+
+// getLabelsFor returns the labels of the given servers keyed by server ID.
+func (db *DB) getLabelsFor(serverIDs []string) (map[string]map[string]string, error) {
+	if len(serverIDs) == 0 {
+		return map[string]map[string]string{}, nil
+	}
+	args := make([]interface{}, len(serverIDs))
+	for i, id := range serverIDs {
+		args[i] = id
+	}
+	return scanLabels(db.Query(`SELECT server_id, key, value FROM server_labels
+	                            WHERE server_id IN (?`+strings.Repeat(", ?", len(serverIDs)-1)+`)`, args...))
+}
+
+func (db *DB) getLabels(serverID string) (map[string]string, error) {
+	labels, err := scanLabels(db.Query(`SELECT server_id, key, value FROM server_labels WHERE server_id = ?`, serverID))
+	return labels[serverID], err
+}
+
+// scanLabels reads server_id, key, value rows into labels keyed by server ID.
+func scanLabels(rows *sql.Rows, err error) (map[string]map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make(map[string]map[string]string)
+	for rows.Next() {
+		var serverID, key, value string
+		if err := rows.Scan(&serverID, &key, &value); err != nil {
+			return nil, err
+		}
+		if labels[serverID] == nil {
+			labels[serverID] = make(map[string]string)
+		}
+		labels[serverID][key] = value
+	}
+
+	return labels, rows.Err()
+}
+
+// replaceServerLabels stores labels as the server's complete label set. Agents
+// send their labels with every report, so nothing is written unless they
+// changed.
+func (tx *Tx) replaceServerLabels(serverID string, labels map[string]string) error {
+	existing, err := scanLabels(tx.Query(`SELECT server_id, key, value FROM server_labels WHERE server_id = ?`, serverID))
+	if err != nil {
+		return err
+	}
+	if sameLabels(existing[serverID], labels) {
+		return nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM server_labels WHERE server_id = ?`, serverID); err != nil {
+		return err
+	}
+	for key, value := range labels {
+		_, err := tx.Exec(`INSERT INTO server_labels (server_id, key, value) VALUES (?, ?, ?)`, serverID, key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sameLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+var serverSortColumns = map[string]string{
+	"name":           "s.name",
+	"id":             "s.id",
+	"ip":             "s.ip",
+	"status":         "s.status",
+	"lifecycle":      "s.lifecycle",
+	"os":             "s.os",
+	"location":       "s.location",
+	"last_heartbeat": "s.last_heartbeat",
+	"created_at":     "s.created_at",
+	// 没有指标的服务器排在最小值一侧
+	"cpu":     "COALESCE(m.cpu, -1)",
+	"memory":  "COALESCE(m.memory, -1)",
+	"network": "COALESCE(m.network_in + m.network_out, -1)",
+}
+
+// escapeLike escapes LIKE wildcards so search text is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ListServers returns a page of servers with their latest metrics and the
+// total number of servers matching the query.
+func (db *DB) ListServers(q model.ServerQuery) ([]model.ServerListItem, int, error) {
+	column, ok := serverSortColumns[q.Sort]
+	if q.Sort == "" {
+		column, ok = "s.name", true
+	}
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sort field %q", q.Sort)
+	}
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+
+	var where []string
+	var args []interface{}
+	if q.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(q.Search)) + "%"
+		where = append(where, `(LOWER(s.name) LIKE ? ESCAPE '\' OR LOWER(s.ip) LIKE ? ESCAPE '\'
+			OR LOWER(s.location) LIKE ? ESCAPE '\' OR LOWER(s.id) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	if q.Status != "" {
+		where = append(where, "s.status = ?")
+		args = append(args, q.Status)
+	}
+	switch q.Lifecycle {
+	case "":
+		where = append(where, "s.lifecycle <> ?")
+		args = append(args, model.LifecycleArchived)
+	case "all":
+	default:
+		where = append(where, "s.lifecycle = ?")
+		args = append(args, q.Lifecycle)
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM servers s `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT s.id, s.name, s.ip, s.status, s.lifecycle, s.os, s.location, s.last_heartbeat, s.created_at, s.updated_at,
+	          m.timestamp, m.cpu, m.memory, m.disk_read, m.disk_write, m.network_in, m.network_out
+	          FROM servers s
+	          LEFT JOIN metrics m ON m.id = (
+	              SELECT id FROM metrics WHERE server_id = s.id ORDER BY timestamp DESC LIMIT 1
+	          )
+	          ` + filter + `
+	          ORDER BY ` + column + ` ` + direction + `, s.id ` + direction
+	if q.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []model.ServerListItem{}
+	for rows.Next() {
+		var item model.ServerListItem
+		var ts sql.NullTime
+		var cpu, memory, diskRead, diskWrite, netIn, netOut sql.NullFloat64
+		s := &item.Server
+		err := rows.Scan(&s.ID, &s.Name, &s.IP, &s.Status, &s.Lifecycle, &s.OS, &s.Location,
+			&s.LastHeartbeat, &s.CreatedAt, &s.UpdatedAt,
+			&ts, &cpu, &memory, &diskRead, &diskWrite, &netIn, &netOut)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ts.Valid {
+			item.Metrics = &model.Metrics{
+				ServerID: s.ID, Timestamp: ts.Time, CPU: cpu.Float64, Memory: memory.Float64,
+				DiskRead: diskRead.Float64, DiskWrite: diskWrite.Float64,
+				NetworkIn: netIn.Float64, NetworkOut: netOut.Float64,
+			}
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// 只查询当前页服务器的标签；不分页时直接读全部
+	var labels map[string]map[string]string
+	if q.Limit > 0 {
+		ids := make([]string, len(items))
+		for i := range items {
+			ids[i] = items[i].ID
+		}
+		labels, err = db.getLabelsFor(ids)
+	} else {
+		labels, err = db.getAllLabels()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		items[i].Labels = labels[items[i].ID]
+	}
+
+	return items, total, nil
+}
+
 func (db *DB) GetServer(id string) (*model.Server, error) {
-	query := `SELECT id, name, ip, status, os, location, last_heartbeat, created_at, updated_at
+	query := `SELECT id, name, ip, status, lifecycle, os, location, last_heartbeat, created_at, updated_at
 	          FROM servers WHERE id = ?`
 
 	var s model.Server
-	err := db.QueryRow(query, id).Scan(&s.ID, &s.Name, &s.IP, &s.Status, &s.OS,
+	err := db.QueryRow(query, id).Scan(&s.ID, &s.Name, &s.IP, &s.Status, &s.Lifecycle, &s.OS,
 		&s.Location, &s.LastHeartbeat, &s.CreatedAt, &s.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("server not found")
 	}
+	if err != nil {
+		return nil, err
+	}
 
+	s.Labels, err = db.getLabels(id)
 	return &s, err
 }
 
@@ -183,6 +474,11 @@ func (db *DB) DeleteServer(id string) error {
 		return err
 	}
 
+	_, err = tx.Exec(`DELETE FROM server_labels WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
 	// Delete related metrics
 	_, err = tx.Exec(`DELETE FROM metrics WHERE server_id = ?`, id)
 	if err != nil {
@@ -213,16 +509,62 @@ func (db *DB) DeleteServer(id string) error {
 		return err
 	}
 
+	// Delete related agent status
+	_, err = tx.Exec(`DELETE FROM agent_status WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM agent_collectors WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	// Delete related inventory
+	_, err = tx.Exec(`DELETE FROM inventory WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	// Delete related packages
+	_, err = tx.Exec(`DELETE FROM packages WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM package_changes WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	// Delete related sensor readings
+	_, err = tx.Exec(`DELETE FROM sensor_readings WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	// Delete related anomalies
+	_, err = tx.Exec(`DELETE FROM anomalies WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
 func (db *DB) InsertMetrics(metrics *model.Metrics) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.insertMetrics(metrics)
+	})
+}
+
+func (tx *Tx) insertMetrics(metrics *model.Metrics) error {
 	query := `
 	INSERT INTO metrics (server_id, timestamp, cpu, memory, disk_read, disk_write, network_in, network_out)
 	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := db.Exec(query, metrics.ServerID, metrics.Timestamp, metrics.CPU,
+	_, err := tx.Exec(query, metrics.ServerID, metrics.Timestamp, metrics.CPU,
 		metrics.Memory, metrics.DiskRead, metrics.DiskWrite, metrics.NetworkIn, metrics.NetworkOut)
 	return err
 }
@@ -242,12 +584,88 @@ func (db *DB) GetLatestMetrics(serverID string) (*model.Metrics, error) {
 	return &m, err
 }
 
-func (db *DB) GetMetricsHistory(serverID string, duration time.Duration) ([]model.Metrics, error) {
+// GetMetricsRange returns the metrics of several servers in [start, end),
+// keyed by server ID and in ascending time order.
+func (db *DB) GetMetricsRange(serverIDs []string, start, end time.Time) (map[string][]model.Metrics, error) {
+	metrics := make(map[string][]model.Metrics)
+	if len(serverIDs) == 0 {
+		return metrics, nil
+	}
+
+	args := make([]interface{}, 0, len(serverIDs)+2)
+	for _, id := range serverIDs {
+		args = append(args, id)
+	}
+	args = append(args, start, end)
+
+	query := `SELECT server_id, timestamp, cpu, memory, disk_read, disk_write, network_in, network_out
+	          FROM metrics WHERE server_id IN (?` + strings.Repeat(", ?", len(serverIDs)-1) + `)
+	          AND timestamp >= ? AND timestamp < ? ORDER BY server_id, timestamp ASC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m model.Metrics
+		err := rows.Scan(&m.ServerID, &m.Timestamp, &m.CPU, &m.Memory,
+			&m.DiskRead, &m.DiskWrite, &m.NetworkIn, &m.NetworkOut)
+		if err != nil {
+			return nil, err
+		}
+		metrics[m.ServerID] = append(metrics[m.ServerID], m)
+	}
+
+	return metrics, rows.Err()
+}
+
+// EachMetric streams the metrics of the given servers (all servers when
+// serverIDs is empty) in [start, end) to fn, one row at a time, ordered by
+// server and time. Iteration stops at the first error returned by fn.
+func (db *DB) EachMetric(serverIDs []string, start, end time.Time, fn func(m *model.Metrics) error) error {
+	var args []interface{}
+	filter := ""
+	if len(serverIDs) > 0 {
+		filter = `server_id IN (?` + strings.Repeat(", ?", len(serverIDs)-1) + `) AND `
+		for _, id := range serverIDs {
+			args = append(args, id)
+		}
+	}
+	args = append(args, start, end)
+
+	query := `SELECT server_id, timestamp, cpu, memory, disk_read, disk_write, network_in, network_out
+	          FROM metrics WHERE ` + filter + `timestamp >= ? AND timestamp < ?
+	          ORDER BY server_id, timestamp ASC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var m model.Metrics
+	for rows.Next() {
+		err := rows.Scan(&m.ServerID, &m.Timestamp, &m.CPU, &m.Memory,
+			&m.DiskRead, &m.DiskWrite, &m.NetworkIn, &m.NetworkOut)
+		if err != nil {
+			return err
+		}
+		if err := fn(&m); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetMetricsHistory returns the metrics of a server in [start, end).
+func (db *DB) GetMetricsHistory(serverID string, start, end time.Time) ([]model.Metrics, error) {
 	query := `SELECT server_id, timestamp, cpu, memory, disk_read, disk_write, network_in, network_out
-	          FROM metrics WHERE server_id = ? AND timestamp >= ? ORDER BY timestamp ASC`
+	          FROM metrics WHERE server_id = ? AND timestamp >= ? AND timestamp < ? ORDER BY timestamp ASC`
 
-	since := time.Now().Add(-duration)
-	rows, err := db.Query(query, serverID, since)
+	rows, err := db.Query(query, serverID, start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -268,6 +686,12 @@ func (db *DB) GetMetricsHistory(serverID string, duration time.Duration) ([]mode
 }
 
 func (db *DB) UpsertServerInfo(info *model.ServerInfo) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.upsertServerInfo(info)
+	})
+}
+
+func (tx *Tx) upsertServerInfo(info *model.ServerInfo) error {
 	query := `
 	INSERT INTO server_info (server_id, cpu_cores, total_memory, used_memory, uptime, updated_at)
 	VALUES (?, ?, ?, ?, ?, ?)
@@ -279,7 +703,7 @@ func (db *DB) UpsertServerInfo(info *model.ServerInfo) error {
 		updated_at = excluded.updated_at
 	`
 
-	_, err := db.Exec(query, info.ServerID, info.CPUCores, info.TotalMemory,
+	_, err := tx.Exec(query, info.ServerID, info.CPUCores, info.TotalMemory,
 		info.UsedMemory, info.Uptime, time.Now())
 	return err
 }
@@ -299,14 +723,14 @@ func (db *DB) GetServerInfo(serverID string) (*model.ServerInfo, error) {
 }
 
 func (db *DB) ReplaceDisks(serverID string, disks []model.Disk) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	return db.inTx(func(tx *Tx) error {
+		return tx.replaceDisks(serverID, disks)
+	})
+}
 
+func (tx *Tx) replaceDisks(serverID string, disks []model.Disk) error {
 	// Delete old disks
-	_, err = tx.Exec(`DELETE FROM disks WHERE server_id = ?`, serverID)
+	_, err := tx.Exec(`DELETE FROM disks WHERE server_id = ?`, serverID)
 	if err != nil {
 		return err
 	}
@@ -329,7 +753,7 @@ func (db *DB) ReplaceDisks(serverID string, disks []model.Disk) error {
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 func (db *DB) GetDisks(serverID string) ([]model.Disk, error) {
@@ -356,15 +780,41 @@ func (db *DB) GetDisks(serverID string) ([]model.Disk, error) {
 	return disks, nil
 }
 
-func (db *DB) ReplaceProcesses(serverID string, processes []model.Process) error {
-	tx, err := db.Begin()
+// GetAllDisks returns the disks of every server keyed by server ID.
+func (db *DB) GetAllDisks() (map[string][]model.Disk, error) {
+	query := `SELECT server_id, name, mount_point, fs_type, total_size, used_size, available_size, usage_percent
+	          FROM disks`
+
+	rows, err := db.Query(query)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer tx.Rollback()
+	defer rows.Close()
+
+	disks := make(map[string][]model.Disk)
+	for rows.Next() {
+		var serverID string
+		var d model.Disk
+		err := rows.Scan(&serverID, &d.Name, &d.MountPoint, &d.FSType, &d.TotalSize,
+			&d.UsedSize, &d.AvailableSize, &d.UsagePercent)
+		if err != nil {
+			return nil, err
+		}
+		disks[serverID] = append(disks[serverID], d)
+	}
+
+	return disks, rows.Err()
+}
 
+func (db *DB) ReplaceProcesses(serverID string, processes []model.Process) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.replaceProcesses(serverID, processes)
+	})
+}
+
+func (tx *Tx) replaceProcesses(serverID string, processes []model.Process) error {
 	// Delete old processes
-	_, err = tx.Exec(`DELETE FROM processes WHERE server_id = ?`, serverID)
+	_, err := tx.Exec(`DELETE FROM processes WHERE server_id = ?`, serverID)
 	if err != nil {
 		return err
 	}
@@ -387,7 +837,7 @@ func (db *DB) ReplaceProcesses(serverID string, processes []model.Process) error
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 func (db *DB) GetProcesses(serverID string, sortBy string, limit int) ([]model.Process, error) {
@@ -419,14 +869,14 @@ func (db *DB) GetProcesses(serverID string, sortBy string, limit int) ([]model.P
 }
 
 func (db *DB) ReplaceNetworkInterfaces(serverID string, interfaces []model.NetworkInterface) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Delete old interfaces
-	_, err = tx.Exec(`DELETE FROM network_interfaces WHERE server_id = ?`, serverID)
+	return db.inTx(func(tx *Tx) error {
+		return tx.replaceNetworkInterfaces(serverID, interfaces)
+	})
+}
+
+func (tx *Tx) replaceNetworkInterfaces(serverID string, interfaces []model.NetworkInterface) error {
+	// Delete old interfaces
+	_, err := tx.Exec(`DELETE FROM network_interfaces WHERE server_id = ?`, serverID)
 	if err != nil {
 		return err
 	}
@@ -449,7 +899,7 @@ func (db *DB) ReplaceNetworkInterfaces(serverID string, interfaces []model.Netwo
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 func (db *DB) GetNetworkInterfaces(serverID string) ([]model.NetworkInterface, error) {
@@ -476,26 +926,574 @@ func (db *DB) GetNetworkInterfaces(serverID string) ([]model.NetworkInterface, e
 	return interfaces, nil
 }
 
-func (db *DB) UpdateServerStatus() error {
+func (db *DB) UpsertAgentStatus(serverID string, status *model.AgentStatus) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.upsertAgentStatus(serverID, status)
+	})
+}
+
+func (tx *Tx) upsertAgentStatus(serverID string, status *model.AgentStatus) error {
+	now := time.Now()
+	_, err := tx.Exec(`
+		INSERT INTO agent_status (server_id, version, started_at, rss, cpu, goroutines, queue_depth,
+			reports_sent, reports_failed, last_success, last_error, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(server_id) DO UPDATE SET
+			version = excluded.version,
+			started_at = excluded.started_at,
+			rss = excluded.rss,
+			cpu = excluded.cpu,
+			goroutines = excluded.goroutines,
+			queue_depth = excluded.queue_depth,
+			reports_sent = excluded.reports_sent,
+			reports_failed = excluded.reports_failed,
+			last_success = excluded.last_success,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at
+	`, serverID, status.Version, status.StartedAt, status.RSS, status.CPU, status.Goroutines,
+		status.QueueDepth, status.ReportsSent, status.ReportsFailed, status.LastSuccess,
+		status.LastError, now)
+	if err != nil {
+		return err
+	}
+
+	// Replace collector statistics
+	_, err = tx.Exec(`DELETE FROM agent_collectors WHERE server_id = ?`, serverID)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO agent_collectors (server_id, name, last_run, last_duration, successes, failures, last_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, cs := range status.Collectors {
+		_, err = stmt.Exec(serverID, cs.Name, cs.LastRun, cs.LastDuration,
+			cs.Successes, cs.Failures, cs.LastError)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) GetAgentStatus(serverID string) (*model.AgentStatus, error) {
+	query := `SELECT version, started_at, rss, cpu, goroutines, queue_depth, reports_sent,
+	                 reports_failed, last_success, last_error, updated_at
+	          FROM agent_status WHERE server_id = ?`
+
+	var s model.AgentStatus
+	var lastSuccess sql.NullTime
+	err := db.QueryRow(query, serverID).Scan(&s.Version, &s.StartedAt, &s.RSS, &s.CPU,
+		&s.Goroutines, &s.QueueDepth, &s.ReportsSent, &s.ReportsFailed, &lastSuccess,
+		&s.LastError, &s.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastSuccess.Valid {
+		s.LastSuccess = &lastSuccess.Time
+	}
+
+	rows, err := db.Query(`SELECT name, last_run, last_duration, successes, failures, last_error
+	                       FROM agent_collectors WHERE server_id = ? ORDER BY name`, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	s.Collectors = []model.CollectorStatus{}
+	for rows.Next() {
+		var cs model.CollectorStatus
+		err := rows.Scan(&cs.Name, &cs.LastRun, &cs.LastDuration, &cs.Successes,
+			&cs.Failures, &cs.LastError)
+		if err != nil {
+			return nil, err
+		}
+		s.Collectors = append(s.Collectors, cs)
+	}
+
+	return &s, nil
+}
+
+func (db *DB) UpsertInventory(inv *model.Inventory) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.upsertInventory(inv)
+	})
+}
+
+func (tx *Tx) upsertInventory(inv *model.Inventory) error {
+	query := `
+	INSERT INTO inventory (server_id, hostname, os, platform, platform_family, platform_version,
+		kernel_version, kernel_arch, virtualization_system, virtualization_role, boot_time,
+		cpu_model, cpu_cores, total_memory, total_disk, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(server_id) DO UPDATE SET
+		hostname = excluded.hostname,
+		os = excluded.os,
+		platform = excluded.platform,
+		platform_family = excluded.platform_family,
+		platform_version = excluded.platform_version,
+		kernel_version = excluded.kernel_version,
+		kernel_arch = excluded.kernel_arch,
+		virtualization_system = excluded.virtualization_system,
+		virtualization_role = excluded.virtualization_role,
+		boot_time = excluded.boot_time,
+		cpu_model = excluded.cpu_model,
+		cpu_cores = excluded.cpu_cores,
+		total_memory = excluded.total_memory,
+		total_disk = excluded.total_disk,
+		updated_at = excluded.updated_at
+	`
+
+	_, err := tx.Exec(query, inv.ServerID, inv.Hostname, inv.OS, inv.Platform, inv.PlatformFamily,
+		inv.PlatformVersion, inv.KernelVersion, inv.KernelArch, inv.VirtualizationSystem,
+		inv.VirtualizationRole, inv.BootTime, inv.CPUModel, inv.CPUCores, inv.TotalMemory,
+		inv.TotalDisk, time.Now())
+	return err
+}
+
+const inventoryColumns = `i.server_id, s.name, i.hostname, i.os, i.platform, i.platform_family,
+	i.platform_version, i.kernel_version, i.kernel_arch, i.virtualization_system,
+	i.virtualization_role, i.boot_time, i.cpu_model, i.cpu_cores, i.total_memory,
+	i.total_disk, i.updated_at`
+
+func scanInventory(row interface{ Scan(...interface{}) error }) (*model.Inventory, error) {
+	var inv model.Inventory
+	var name sql.NullString
+	err := row.Scan(&inv.ServerID, &name, &inv.Hostname, &inv.OS, &inv.Platform,
+		&inv.PlatformFamily, &inv.PlatformVersion, &inv.KernelVersion, &inv.KernelArch,
+		&inv.VirtualizationSystem, &inv.VirtualizationRole, &inv.BootTime, &inv.CPUModel,
+		&inv.CPUCores, &inv.TotalMemory, &inv.TotalDisk, &inv.UpdatedAt)
+	inv.ServerName = name.String
+	return &inv, err
+}
+
+func (db *DB) GetInventory(serverID string) (*model.Inventory, error) {
+	query := `SELECT ` + inventoryColumns + `
+	          FROM inventory i LEFT JOIN servers s ON s.id = i.server_id
+	          WHERE i.server_id = ?`
+
+	inv, err := scanInventory(db.QueryRow(query, serverID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return inv, err
+}
+
+func (db *DB) ListInventory(filter model.InventoryFilter) ([]model.Inventory, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Platform != "" {
+		conditions = append(conditions, "i.platform = ?")
+		args = append(args, filter.Platform)
+	}
+	if filter.PlatformFamily != "" {
+		conditions = append(conditions, "i.platform_family = ?")
+		args = append(args, filter.PlatformFamily)
+	}
+	if filter.PlatformVersion != "" {
+		conditions = append(conditions, "i.platform_version = ?")
+		args = append(args, filter.PlatformVersion)
+	}
+	if filter.Kernel != "" {
+		conditions = append(conditions, `i.kernel_version LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(filter.Kernel)+"%")
+	}
+	if filter.Arch != "" {
+		conditions = append(conditions, "i.kernel_arch = ?")
+		args = append(args, filter.Arch)
+	}
+
+	query := `SELECT ` + inventoryColumns + `
+	          FROM inventory i JOIN servers s ON s.id = i.server_id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY s.name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inventory := []model.Inventory{}
+	for rows.Next() {
+		inv, err := scanInventory(rows)
+		if err != nil {
+			return nil, err
+		}
+		inventory = append(inventory, *inv)
+	}
+
+	return inventory, nil
+}
+
+// ApplyPackageReport stores a full package list or a diff and records the
+// resulting changes. The first full list for a server is taken as the
+// baseline and not recorded as changes.
+func (db *DB) ApplyPackageReport(serverID string, report *model.PackageReport, at time.Time) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.applyPackageReport(serverID, report, at)
+	})
+}
+
+func (tx *Tx) applyPackageReport(serverID string, report *model.PackageReport, at time.Time) error {
+	// Load current packages
+	rows, err := tx.Query(`SELECT source, name, arch, version FROM packages WHERE server_id = ?`, serverID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]model.Package)
+	for rows.Next() {
+		var p model.Package
+		if err := rows.Scan(&p.Source, &p.Name, &p.Arch, &p.Version); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[p.Key()] = p
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	upserts, removed, changes := diffPackages(serverID, existing, report, at)
+
+	upsert, err := tx.Prepare(`
+		INSERT INTO packages (server_id, source, name, arch, version, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(server_id, source, name, arch) DO UPDATE SET
+			version = excluded.version,
+			updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer upsert.Close()
+
+	remove, err := tx.Prepare(`DELETE FROM packages WHERE server_id = ? AND source = ? AND name = ? AND arch = ?`)
+	if err != nil {
+		return err
+	}
+	defer remove.Close()
+
+	record, err := tx.Prepare(`
+		INSERT INTO package_changes (server_id, source, name, arch, action, old_version, new_version, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer record.Close()
+
+	for _, p := range upserts {
+		if _, err := upsert.Exec(serverID, p.Source, p.Name, p.Arch, p.Version, at); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range removed {
+		if _, err := remove.Exec(serverID, p.Source, p.Name, p.Arch); err != nil {
+			return err
+		}
+	}
+
+	for _, ch := range changes {
+		_, err := record.Exec(serverID, ch.Source, ch.Name, ch.Arch, ch.Action,
+			ch.OldVersion, ch.NewVersion, ch.ChangedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindPackages returns every server that has the named package installed.
+func (db *DB) FindPackages(name string) ([]model.ServerPackage, error) {
+	query := `SELECT p.server_id, s.name, p.name, p.version, p.arch, p.source, p.updated_at
+	          FROM packages p JOIN servers s ON s.id = p.server_id
+	          WHERE p.name = ? ORDER BY s.name`
+
+	rows, err := db.Query(query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packages := []model.ServerPackage{}
+	for rows.Next() {
+		var p model.ServerPackage
+		err := rows.Scan(&p.ServerID, &p.ServerName, &p.Name, &p.Version, &p.Arch,
+			&p.Source, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, p)
+	}
+
+	return packages, nil
+}
+
+func (db *DB) GetPackages(serverID string) ([]model.Package, error) {
+	query := `SELECT name, version, arch, source FROM packages WHERE server_id = ? ORDER BY name`
+
+	rows, err := db.Query(query, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packages := []model.Package{}
+	for rows.Next() {
+		var p model.Package
+		if err := rows.Scan(&p.Name, &p.Version, &p.Arch, &p.Source); err != nil {
+			return nil, err
+		}
+		packages = append(packages, p)
+	}
+
+	return packages, nil
+}
+
+func (db *DB) GetPackageChanges(serverID string, start, end time.Time) ([]model.PackageChange, error) {
+	query := `SELECT server_id, name, arch, source, action, old_version, new_version, changed_at
+	          FROM package_changes WHERE server_id = ? AND changed_at >= ? AND changed_at < ?
+	          ORDER BY changed_at DESC, id DESC`
+
+	rows, err := db.Query(query, serverID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []model.PackageChange{}
+	for rows.Next() {
+		var ch model.PackageChange
+		err := rows.Scan(&ch.ServerID, &ch.Name, &ch.Arch, &ch.Source, &ch.Action,
+			&ch.OldVersion, &ch.NewVersion, &ch.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, ch)
+	}
+
+	return changes, nil
+}
+
+func (db *DB) InsertSensorReadings(serverID string, timestamp time.Time, sensors []model.Sensor) error {
+	return db.inTx(func(tx *Tx) error {
+		return tx.insertSensorReadings(serverID, timestamp, sensors)
+	})
+}
+
+func (tx *Tx) insertSensorReadings(serverID string, timestamp time.Time, sensors []model.Sensor) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO sensor_readings (server_id, timestamp, label, kind, value, high, critical)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, sensor := range sensors {
+		_, err = stmt.Exec(serverID, timestamp, sensor.Label, sensor.Kind, sensor.Value,
+			sensor.High, sensor.Critical)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetLatestSensors returns the most recent reading of every sensor.
+func (db *DB) GetLatestSensors(serverID string) ([]model.Sensor, error) {
+	query := `SELECT label, kind, value, high, critical FROM sensor_readings
+	          WHERE server_id = ? AND timestamp = (
+	              SELECT MAX(timestamp) FROM sensor_readings WHERE server_id = ?)
+	          ORDER BY kind DESC, label`
+
+	rows, err := db.Query(query, serverID, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sensors := []model.Sensor{}
+	for rows.Next() {
+		var s model.Sensor
+		if err := rows.Scan(&s.Label, &s.Kind, &s.Value, &s.High, &s.Critical); err != nil {
+			return nil, err
+		}
+		sensors = append(sensors, s)
+	}
+
+	return sensors, nil
+}
+
+// GetSensorHistory returns one series per sensor in [start, end).
+func (db *DB) GetSensorHistory(serverID string, start, end time.Time) ([]model.SensorSeries, error) {
+	query := `SELECT label, kind, timestamp, value FROM sensor_readings
+	          WHERE server_id = ? AND timestamp >= ? AND timestamp < ? ORDER BY kind DESC, label, timestamp ASC`
+
+	rows, err := db.Query(query, serverID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []model.SensorSeries{}
+	for rows.Next() {
+		var label, kind string
+		var point model.SensorPoint
+		if err := rows.Scan(&label, &kind, &point.Timestamp, &point.Value); err != nil {
+			return nil, err
+		}
+
+		n := len(series)
+		if n == 0 || series[n-1].Label != label || series[n-1].Kind != kind {
+			series = append(series, model.SensorSeries{Label: label, Kind: kind})
+			n++
+		}
+		series[n-1].Points = append(series[n-1].Points, point)
+	}
+
+	return series, nil
+}
+
+func (db *DB) UpdateServerStatus(maintenance []string) error {
 	// Set servers to warning if heartbeat > 30s, offline if > 60s
 	now := time.Now()
 
+	inMaintenance := `lifecycle = 'maintenance'`
+	var args []interface{}
+	if len(maintenance) > 0 {
+		inMaintenance += ` OR id IN (` + placeholders(len(maintenance)) + `)`
+		for _, id := range maintenance {
+			args = append(args, id)
+		}
+	}
+
+	if db.isPostgres() {
+		_, err := db.Exec(`
+			UPDATE servers
+			SET status = CASE
+				WHEN `+inMaintenance+` THEN 'maintenance'
+				WHEN last_heartbeat < ? THEN 'offline'
+				WHEN last_heartbeat < ? THEN 'warning'
+				ELSE 'online'
+			END
+			WHERE last_heartbeat IS NOT NULL
+		`, append(args, now.Add(-60*time.Second), now.Add(-30*time.Second))...)
+		return err
+	}
+
 	_, err := db.Exec(`
 		UPDATE servers
 		SET status = CASE
+			WHEN `+inMaintenance+` THEN 'maintenance'
 			WHEN julianday(?) - julianday(last_heartbeat) > (60.0 / 86400.0) THEN 'offline'
 			WHEN julianday(?) - julianday(last_heartbeat) > (30.0 / 86400.0) THEN 'warning'
 			ELSE 'online'
 		END
 		WHERE last_heartbeat IS NOT NULL
-	`, now, now)
+	`, append(args, now, now)...)
 
 	return err
 }
 
-func (db *DB) CleanupOldData(retentionDays int) error {
-	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+// SaveReports writes a batch of agent reports in a single transaction, which
+// is far cheaper than a transaction per statement.
+func (db *DB) SaveReports(reports []model.ReceivedReport) error {
+	return db.inTx(func(tx *Tx) error {
+		for _, r := range reports {
+			if err := tx.saveReport(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
 
-	_, err := db.Exec(`DELETE FROM metrics WHERE timestamp < ?`, cutoff)
-	return err
+func (tx *Tx) saveReport(r model.ReceivedReport) error {
+	report := r.Report
+
+	// 报告入队后服务器可能已被删除并加入黑名单
+	var blocked int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM blocked_servers WHERE server_id = ?`, report.ServerID).Scan(&blocked)
+	if err != nil || blocked > 0 {
+		return err
+	}
+
+	if err := tx.upsertServer(&r.Server); err != nil {
+		return err
+	}
+	if report.Labels != nil {
+		if err := tx.replaceServerLabels(report.ServerID, report.Labels); err != nil {
+			return err
+		}
+	}
+
+	if report.Metrics != nil {
+		if err := tx.insertMetrics(report.Metrics); err != nil {
+			return err
+		}
+	}
+	if report.Info != nil {
+		if err := tx.upsertServerInfo(report.Info); err != nil {
+			return err
+		}
+	}
+	if len(report.Disks) > 0 {
+		if err := tx.replaceDisks(report.ServerID, report.Disks); err != nil {
+			return err
+		}
+	}
+	if len(report.Processes) > 0 {
+		if err := tx.replaceProcesses(report.ServerID, report.Processes); err != nil {
+			return err
+		}
+	}
+	if len(report.Network) > 0 {
+		if err := tx.replaceNetworkInterfaces(report.ServerID, report.Network); err != nil {
+			return err
+		}
+	}
+	if report.Inventory != nil {
+		if err := tx.upsertInventory(report.Inventory); err != nil {
+			return err
+		}
+	}
+	if len(report.Sensors) > 0 {
+		if err := tx.insertSensorReadings(report.ServerID, report.Timestamp, report.Sensors); err != nil {
+			return err
+		}
+	}
+	if report.Packages != nil {
+		if err := tx.applyPackageReport(report.ServerID, report.Packages, report.Timestamp); err != nil {
+			return err
+		}
+	}
+	if report.Agent != nil {
+		if err := tx.upsertAgentStatus(report.ServerID, report.Agent); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := New(filepath.Join(t.TempDir(), "monitor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func labelReport(id string, labels map[string]string) model.ReceivedReport {
+	now := time.Now()
+	return model.ReceivedReport{
+		Server: model.Server{ID: id, Name: id, Status: "online", LastHeartbeat: now},
+		Report: &model.AgentReport{ServerID: id, Labels: labels, Timestamp: now},
+	}
+}
+
+func TestSaveReportsLabels(t *testing.T) {
+	db := openTestDB(t)
+	// 记录对标签表的删除次数
+	_, err := db.Exec(`CREATE TABLE label_deletes (n INTEGER);
+		CREATE TRIGGER count_label_deletes AFTER DELETE ON server_labels
+		BEGIN INSERT INTO label_deletes VALUES (1); END;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletes := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM label_deletes`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	labels := map[string]string{"env": "prod", "role": "web"}
+	if err := db.SaveReports([]model.ReceivedReport{labelReport("a", labels)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 标签未变化时不重写
+	if err := db.SaveReports([]model.ReceivedReport{labelReport("a", map[string]string{"role": "web", "env": "prod"})}); err != nil {
+		t.Fatal(err)
+	}
+	if n := deletes(); n != 0 {
+		t.Errorf("unchanged labels were rewritten (%d rows deleted)", n)
+	}
+
+	// 标签变化时替换整组标签
+	if err := db.SaveReports([]model.ReceivedReport{labelReport("a", map[string]string{"env": "staging"})}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := db.GetServer("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"env": "staging"}; !reflect.DeepEqual(s.Labels, want) {
+		t.Errorf("labels = %v, want %v", s.Labels, want)
+	}
+}
+
+func TestListServersPageLabels(t *testing.T) {
+	db := openTestDB(t)
+	var reports []model.ReceivedReport
+	for _, id := range []string{"a", "b", "c"} {
+		reports = append(reports, labelReport(id, map[string]string{"name": id}))
+	}
+	if err := db.SaveReports(reports); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range []model.ServerQuery{{Limit: 2, Offset: 1}, {}} {
+		items, total, err := db.ListServers(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 3 {
+			t.Errorf("total = %d, want 3", total)
+		}
+		for _, item := range items {
+			if item.Labels["name"] != item.ID {
+				t.Errorf("%+v: server %s has labels %v", q, item.ID, item.Labels)
+			}
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	pg := &DB{driver: "postgres"}
+	tests := []struct {
+		query, want string
+	}{
+		{`SELECT * FROM t WHERE a = ? AND b = ?`, `SELECT * FROM t WHERE a = $1 AND b = $2`},
+		{`SELECT '?' FROM t WHERE a = ?`, `SELECT '?' FROM t WHERE a = $1`},
+		{`SELECT 'it''s ?' FROM t WHERE a LIKE ? ESCAPE '\' AND b = ?`, `SELECT 'it''s ?' FROM t WHERE a LIKE $1 ESCAPE '\' AND b = $2`},
+		{`SELECT "col?" FROM t WHERE a = ?`, `SELECT "col?" FROM t WHERE a = $1`},
+	}
+	for _, tt := range tests {
+		if got := pg.rebind(tt.query); got != tt.want {
+			t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	sqlite := &DB{driver: "sqlite3"}
+	if q := `SELECT ? FROM t`; sqlite.rebind(q) != q {
+		t.Errorf("sqlite query was rewritten: %q", sqlite.rebind(q))
+	}
+}
+
+func TestReportKeepsMaintenanceStatus(t *testing.T) {
+	for name, s := range map[string]Store{"sqlite": openTestDB(t), "memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			report := func() {
+				t.Helper()
+				if err := s.SaveReports([]model.ReceivedReport{labelReport("a", nil)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			status := func(want string) {
+				t.Helper()
+				srv, err := s.GetServer("a")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if srv.Status != want {
+					t.Errorf("status = %q, want %q", srv.Status, want)
+				}
+			}
+
+			report()
+			if err := s.UpdateServerStatus([]string{"a"}); err != nil {
+				t.Fatal(err)
+			}
+			report()
+			status("maintenance")
+
+			// 窗口结束后由状态检查恢复
+			if err := s.UpdateServerStatus(nil); err != nil {
+				t.Fatal(err)
+			}
+			report()
+			status("online")
+
+			if err := s.SetServerLifecycle("a", model.LifecycleMaintenance); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.UpdateServerStatus(nil); err != nil {
+				t.Fatal(err)
+			}
+			report()
+			status("maintenance")
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+// SetServerLifecycle moves a server to another lifecycle state.
+func (db *DB) SetServerLifecycle(id, lifecycle string) error {
+	result, err := db.Exec(`UPDATE servers SET lifecycle = ?, updated_at = ? WHERE id = ?`,
+		lifecycle, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("server not found")
+	}
+	return nil
+}
+
+// ArchiveStaleServers archives active servers whose last heartbeat is older
+// than before and returns how many were archived.
+func (db *DB) ArchiveStaleServers(before time.Time) (int64, error) {
+	result, err := db.Exec(`UPDATE servers SET lifecycle = ?, updated_at = ?
+	                        WHERE lifecycle = ? AND last_heartbeat < ?`,
+		model.LifecycleArchived, time.Now(), model.LifecycleActive, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// BlockServer makes AgentReport reject reports from id.
+func (db *DB) BlockServer(id, reason string) error {
+	_, err := db.Exec(`INSERT INTO blocked_servers (server_id, reason, blocked_at) VALUES (?, ?, ?)
+	                   ON CONFLICT(server_id) DO UPDATE SET reason = excluded.reason`,
+		id, reason, time.Now())
+	return err
+}
+
+func (db *DB) UnblockServer(id string) error {
+	result, err := db.Exec(`DELETE FROM blocked_servers WHERE server_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("server is not blocked")
+	}
+	return nil
+}
+
+func (db *DB) IsServerBlocked(id string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM blocked_servers WHERE server_id = ?`, id).Scan(&n)
+	return n > 0, err
+}
+
+func (db *DB) GetBlockedServers() ([]model.BlockedServer, error) {
+	rows, err := db.Query(`SELECT server_id, COALESCE(reason, ''), blocked_at FROM blocked_servers ORDER BY blocked_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := []model.BlockedServer{}
+	for rows.Next() {
+		var b model.BlockedServer
+		if err := rows.Scan(&b.ServerID, &b.Reason, &b.BlockedAt); err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, b)
+	}
+	return blocked, rows.Err()
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+func (db *DB) CreateMaintenanceWindow(w *model.MaintenanceWindow) error {
+	servers, err := json.Marshal(w.Servers)
+	if err != nil {
+		return err
+	}
+	if w.Servers == nil {
+		servers = []byte("[]")
+	}
+
+	w.CreatedAt = time.Now()
+	return db.QueryRow(`INSERT INTO maintenance_windows
+		(name, servers, selector, start_at, end_at, recurrence, until_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		w.Name, string(servers), w.Selector, w.Start, w.End, w.Recurrence, w.Until, w.CreatedAt,
+	).Scan(&w.ID)
+}
+
+func (db *DB) GetMaintenanceWindows() ([]model.MaintenanceWindow, error) {
+	rows, err := db.Query(`SELECT id, name, servers, selector, start_at, end_at, recurrence, until_at, created_at
+	                       FROM maintenance_windows ORDER BY start_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []model.MaintenanceWindow{}
+	for rows.Next() {
+		var w model.MaintenanceWindow
+		var servers string
+		var until sql.NullTime
+		err := rows.Scan(&w.ID, &w.Name, &servers, &w.Selector, &w.Start, &w.End,
+			&w.Recurrence, &until, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(servers), &w.Servers); err != nil {
+			return nil, err
+		}
+		if until.Valid {
+			w.Until = &until.Time
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+func (db *DB) DeleteMaintenanceWindow(id int64) error {
+	result, err := db.Exec(`DELETE FROM maintenance_windows WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+	return nil
+}
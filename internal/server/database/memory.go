@@ -0,0 +1,1061 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+type sensorReading struct {
+	Timestamp time.Time
+	model.Sensor
+}
+
+type storedPackage struct {
+	model.Package
+	UpdatedAt time.Time
+}
+
+// MemoryStore keeps everything in process memory. It is meant for tests and
+// throwaway instances; nothing survives a restart.
+type MemoryStore struct {
+	mu             sync.RWMutex
+	servers        map[string]model.Server
+	labels         map[string]map[string]string
+	metrics        map[string][]model.Metrics // 按时间升序
+	info           map[string]model.ServerInfo
+	disks          map[string][]model.Disk
+	processes      map[string][]model.Process
+	interfaces     map[string][]model.NetworkInterface
+	agentStatus    map[string]model.AgentStatus
+	inventory      map[string]model.Inventory
+	packages       map[string]map[string]storedPackage
+	packageChanges []model.PackageChange
+	sensors        map[string][]sensorReading // 按时间升序
+	blocked        map[string]model.BlockedServer
+	windows        []model.MaintenanceWindow
+	nextWindowID   int64
+	anomalies      []model.Anomaly
+	nextAnomalyID  int64
+}
+
+func NewMemory() *MemoryStore {
+	return &MemoryStore{
+		servers:     make(map[string]model.Server),
+		labels:      make(map[string]map[string]string),
+		blocked:     make(map[string]model.BlockedServer),
+		metrics:     make(map[string][]model.Metrics),
+		info:        make(map[string]model.ServerInfo),
+		disks:       make(map[string][]model.Disk),
+		processes:   make(map[string][]model.Process),
+		interfaces:  make(map[string][]model.NetworkInterface),
+		agentStatus: make(map[string]model.AgentStatus),
+		inventory:   make(map[string]model.Inventory),
+		packages:    make(map[string]map[string]storedPackage),
+		sensors:     make(map[string][]sensorReading),
+	}
+}
+
+func (m *MemoryStore) Initialize() error {
+	return nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+func (m *MemoryStore) UpsertServer(server *model.Server) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := *server
+	s.Labels = nil // 标签单独保存
+	s.CreatedAt = time.Now()
+	s.Lifecycle = model.LifecycleActive
+	if existing, ok := m.servers[s.ID]; ok {
+		s.CreatedAt = existing.CreatedAt
+		if existing.Lifecycle != model.LifecycleArchived {
+			s.Lifecycle = existing.Lifecycle
+		}
+		// 维护状态只由 UpdateServerStatus 设置和清除
+		if existing.Lifecycle == model.LifecycleMaintenance || existing.Status == "maintenance" {
+			s.Status = existing.Status
+		}
+	}
+	s.UpdatedAt = time.Now()
+	m.servers[s.ID] = s
+	return nil
+}
+
+func (m *MemoryStore) GetServers() ([]model.Server, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	servers := make([]model.Server, 0, len(m.servers))
+	for _, s := range m.servers {
+		s.Labels = m.copyLabels(s.ID)
+		servers = append(servers, s)
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Name < servers[j].Name
+	})
+	return servers, nil
+}
+
+func (m *MemoryStore) ListServers(q model.ServerQuery) ([]model.ServerListItem, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	search := strings.ToLower(q.Search)
+	items := []model.ServerListItem{}
+	for id, s := range m.servers {
+		if q.Status != "" && s.Status != q.Status {
+			continue
+		}
+		switch q.Lifecycle {
+		case "":
+			if s.Lifecycle == model.LifecycleArchived {
+				continue
+			}
+		case "all":
+		default:
+			if s.Lifecycle != q.Lifecycle {
+				continue
+			}
+		}
+		if search != "" && !strings.Contains(strings.ToLower(s.Name), search) &&
+			!strings.Contains(strings.ToLower(s.IP), search) &&
+			!strings.Contains(strings.ToLower(s.Location), search) &&
+			!strings.Contains(strings.ToLower(id), search) {
+			continue
+		}
+		s.Labels = m.copyLabels(id)
+		item := model.ServerListItem{Server: s}
+		if list := m.metrics[id]; len(list) > 0 {
+			latest := list[len(list)-1]
+			item.Metrics = &latest
+		}
+		items = append(items, item)
+	}
+
+	less, err := serverLess(q.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := &items[i], &items[j]
+		if q.Desc {
+			a, b = b, a
+		}
+		if less(a, b) != less(b, a) {
+			return less(a, b)
+		}
+		return a.ID < b.ID
+	})
+
+	total := len(items)
+	if q.Limit > 0 {
+		if q.Offset > len(items) {
+			q.Offset = len(items)
+		}
+		items = items[q.Offset:]
+		if len(items) > q.Limit {
+			items = items[:q.Limit]
+		}
+	}
+	return items, total, nil
+}
+
+func metricValue(item *model.ServerListItem, f func(*model.Metrics) float64) float64 {
+	if item.Metrics == nil {
+		return -1
+	}
+	return f(item.Metrics)
+}
+
+func serverLess(field string) (func(a, b *model.ServerListItem) bool, error) {
+	switch field {
+	case "", "name":
+		return func(a, b *model.ServerListItem) bool { return a.Name < b.Name }, nil
+	case "id":
+		return func(a, b *model.ServerListItem) bool { return a.ID < b.ID }, nil
+	case "ip":
+		return func(a, b *model.ServerListItem) bool { return a.IP < b.IP }, nil
+	case "status":
+		return func(a, b *model.ServerListItem) bool { return a.Status < b.Status }, nil
+	case "lifecycle":
+		return func(a, b *model.ServerListItem) bool { return a.Lifecycle < b.Lifecycle }, nil
+	case "os":
+		return func(a, b *model.ServerListItem) bool { return a.OS < b.OS }, nil
+	case "location":
+		return func(a, b *model.ServerListItem) bool { return a.Location < b.Location }, nil
+	case "last_heartbeat":
+		return func(a, b *model.ServerListItem) bool { return a.LastHeartbeat.Before(b.LastHeartbeat) }, nil
+	case "created_at":
+		return func(a, b *model.ServerListItem) bool { return a.CreatedAt.Before(b.CreatedAt) }, nil
+	case "cpu":
+		return func(a, b *model.ServerListItem) bool {
+			cpu := func(m *model.Metrics) float64 { return m.CPU }
+			return metricValue(a, cpu) < metricValue(b, cpu)
+		}, nil
+	case "memory":
+		return func(a, b *model.ServerListItem) bool {
+			memory := func(m *model.Metrics) float64 { return m.Memory }
+			return metricValue(a, memory) < metricValue(b, memory)
+		}, nil
+	case "network":
+		return func(a, b *model.ServerListItem) bool {
+			network := func(m *model.Metrics) float64 { return m.NetworkIn + m.NetworkOut }
+			return metricValue(a, network) < metricValue(b, network)
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid sort field %q", field)
+}
+
+func (m *MemoryStore) GetServer(id string) (*model.Server, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.servers[id]
+	if !ok {
+		return nil, fmt.Errorf("server not found")
+	}
+	s.Labels = m.copyLabels(id)
+	return &s, nil
+}
+
+func (m *MemoryStore) SetServerLifecycle(id, lifecycle string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.servers[id]
+	if !ok {
+		return fmt.Errorf("server not found")
+	}
+	s.Lifecycle = lifecycle
+	s.UpdatedAt = time.Now()
+	m.servers[id] = s
+	return nil
+}
+
+func (m *MemoryStore) ArchiveStaleServers(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for id, s := range m.servers {
+		if s.Lifecycle == model.LifecycleActive && s.LastHeartbeat.Before(before) {
+			s.Lifecycle = model.LifecycleArchived
+			s.UpdatedAt = time.Now()
+			m.servers[id] = s
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryStore) BlockServer(id, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.blocked[id]
+	if !ok {
+		b = model.BlockedServer{ServerID: id, BlockedAt: time.Now()}
+	}
+	b.Reason = reason
+	m.blocked[id] = b
+	return nil
+}
+
+func (m *MemoryStore) UnblockServer(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.blocked[id]; !ok {
+		return fmt.Errorf("server is not blocked")
+	}
+	delete(m.blocked, id)
+	return nil
+}
+
+func (m *MemoryStore) IsServerBlocked(id string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.blocked[id]
+	return ok, nil
+}
+
+func (m *MemoryStore) GetBlockedServers() ([]model.BlockedServer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blocked := make([]model.BlockedServer, 0, len(m.blocked))
+	for _, b := range m.blocked {
+		blocked = append(blocked, b)
+	}
+	sort.Slice(blocked, func(i, j int) bool {
+		return blocked[i].BlockedAt.After(blocked[j].BlockedAt)
+	})
+	return blocked, nil
+}
+
+func (m *MemoryStore) CreateMaintenanceWindow(w *model.MaintenanceWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextWindowID++
+	w.ID = m.nextWindowID
+	w.CreatedAt = time.Now()
+	stored := *w
+	stored.Servers = append([]string(nil), w.Servers...)
+	m.windows = append(m.windows, stored)
+	return nil
+}
+
+func (m *MemoryStore) GetMaintenanceWindows() ([]model.MaintenanceWindow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	windows := append([]model.MaintenanceWindow{}, m.windows...)
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+	return windows, nil
+}
+
+func (m *MemoryStore) DeleteMaintenanceWindow(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, w := range m.windows {
+		if w.ID == id {
+			m.windows = append(m.windows[:i], m.windows[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("maintenance window not found")
+}
+
+func (m *MemoryStore) SaveAnomaly(a *model.Anomaly) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a.ID == 0 {
+		m.nextAnomalyID++
+		a.ID = m.nextAnomalyID
+		m.anomalies = append(m.anomalies, *a)
+		return nil
+	}
+	for i := range m.anomalies {
+		if m.anomalies[i].ID == a.ID {
+			m.anomalies[i] = *a
+			return nil
+		}
+	}
+	return fmt.Errorf("anomaly %d not found", a.ID)
+}
+
+func (m *MemoryStore) GetAnomalies(serverID string, start, end time.Time) ([]model.Anomaly, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	anomalies := []model.Anomaly{}
+	for i := len(m.anomalies) - 1; i >= 0; i-- {
+		a := m.anomalies[i]
+		if a.ServerID == serverID && a.StartedAt.Before(end) && !a.EndedAt.Before(start) {
+			anomalies = append(anomalies, a)
+		}
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].StartedAt.After(anomalies[j].StartedAt) })
+	return anomalies, nil
+}
+
+func (m *MemoryStore) copyLabels(serverID string) map[string]string {
+	if len(m.labels[serverID]) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(m.labels[serverID]))
+	for k, v := range m.labels[serverID] {
+		labels[k] = v
+	}
+	return labels
+}
+
+func (m *MemoryStore) DeleteServer(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.servers, id)
+	delete(m.labels, id)
+	delete(m.metrics, id)
+	delete(m.info, id)
+	delete(m.disks, id)
+	delete(m.processes, id)
+	delete(m.interfaces, id)
+	delete(m.agentStatus, id)
+	delete(m.inventory, id)
+	delete(m.packages, id)
+	delete(m.sensors, id)
+
+	changes := m.packageChanges[:0]
+	for _, ch := range m.packageChanges {
+		if ch.ServerID != id {
+			changes = append(changes, ch)
+		}
+	}
+	m.packageChanges = changes
+
+	anomalies := m.anomalies[:0]
+	for _, a := range m.anomalies {
+		if a.ServerID != id {
+			anomalies = append(anomalies, a)
+		}
+	}
+	m.anomalies = anomalies
+	return nil
+}
+
+func (m *MemoryStore) UpdateServerStatus(maintenance []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inMaintenance := make(map[string]bool, len(maintenance))
+	for _, id := range maintenance {
+		inMaintenance[id] = true
+	}
+
+	// Set servers to warning if heartbeat > 30s, offline if > 60s
+	now := time.Now()
+	for id, s := range m.servers {
+		if s.LastHeartbeat.IsZero() {
+			continue
+		}
+		age := now.Sub(s.LastHeartbeat)
+		switch {
+		case s.Lifecycle == model.LifecycleMaintenance || inMaintenance[id]:
+			s.Status = "maintenance"
+		case age > 60*time.Second:
+			s.Status = "offline"
+		case age > 30*time.Second:
+			s.Status = "warning"
+		default:
+			s.Status = "online"
+		}
+		m.servers[id] = s
+	}
+	return nil
+}
+
+func (m *MemoryStore) InsertMetrics(metrics *model.Metrics) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := m.metrics[metrics.ServerID]
+	i := sort.Search(len(list), func(i int) bool {
+		return list[i].Timestamp.After(metrics.Timestamp)
+	})
+	list = append(list, model.Metrics{})
+	copy(list[i+1:], list[i:])
+	list[i] = *metrics
+	m.metrics[metrics.ServerID] = list
+	return nil
+}
+
+func (m *MemoryStore) GetLatestMetrics(serverID string) (*model.Metrics, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := m.metrics[serverID]
+	if len(list) == 0 {
+		return nil, nil
+	}
+	latest := list[len(list)-1]
+	return &latest, nil
+}
+
+func (m *MemoryStore) GetMetricsRange(serverIDs []string, start, end time.Time) (map[string][]model.Metrics, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	metrics := make(map[string][]model.Metrics)
+	for _, id := range serverIDs {
+		for _, metric := range m.metrics[id] {
+			if !metric.Timestamp.Before(start) && metric.Timestamp.Before(end) {
+				metrics[id] = append(metrics[id], metric)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func (m *MemoryStore) EachMetric(serverIDs []string, start, end time.Time, fn func(m *model.Metrics) error) error {
+	m.mu.RLock()
+	if len(serverIDs) == 0 {
+		for id := range m.metrics {
+			serverIDs = append(serverIDs, id)
+		}
+	}
+	ids := append([]string(nil), serverIDs...)
+	sort.Strings(ids)
+
+	var rows []model.Metrics
+	for _, id := range ids {
+		for _, metric := range m.metrics[id] {
+			if !metric.Timestamp.Before(start) && metric.Timestamp.Before(end) {
+				rows = append(rows, metric)
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	for i := range rows {
+		if err := fn(&rows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetMetricsHistory(serverID string, start, end time.Time) ([]model.Metrics, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var metrics []model.Metrics
+	for _, metric := range m.metrics[serverID] {
+		if !metric.Timestamp.Before(start) && metric.Timestamp.Before(end) {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+func (m *MemoryStore) UpsertServerInfo(info *model.ServerInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.info[info.ServerID] = *info
+	return nil
+}
+
+func (m *MemoryStore) GetServerInfo(serverID string) (*model.ServerInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	info, ok := m.info[serverID]
+	if !ok {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+func (m *MemoryStore) ReplaceDisks(serverID string, disks []model.Disk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.disks[serverID] = append([]model.Disk(nil), disks...)
+	return nil
+}
+
+func (m *MemoryStore) GetDisks(serverID string) ([]model.Disk, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]model.Disk(nil), m.disks[serverID]...), nil
+}
+
+func (m *MemoryStore) GetAllDisks() (map[string][]model.Disk, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	disks := make(map[string][]model.Disk, len(m.disks))
+	for id, list := range m.disks {
+		disks[id] = append([]model.Disk(nil), list...)
+	}
+	return disks, nil
+}
+
+func (m *MemoryStore) ReplaceProcesses(serverID string, processes []model.Process) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.processes[serverID] = append([]model.Process(nil), processes...)
+	return nil
+}
+
+func (m *MemoryStore) GetProcesses(serverID string, sortBy string, limit int) ([]model.Process, error) {
+	m.mu.RLock()
+	processes := append([]model.Process(nil), m.processes[serverID]...)
+	m.mu.RUnlock()
+
+	sort.SliceStable(processes, func(i, j int) bool {
+		if sortBy == "memory" {
+			return processes[i].Memory > processes[j].Memory
+		}
+		return processes[i].CPU > processes[j].CPU
+	})
+	if limit >= 0 && len(processes) > limit {
+		processes = processes[:limit]
+	}
+	return processes, nil
+}
+
+func (m *MemoryStore) ReplaceNetworkInterfaces(serverID string, interfaces []model.NetworkInterface) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.interfaces[serverID] = append([]model.NetworkInterface(nil), interfaces...)
+	return nil
+}
+
+func (m *MemoryStore) GetNetworkInterfaces(serverID string) ([]model.NetworkInterface, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]model.NetworkInterface(nil), m.interfaces[serverID]...), nil
+}
+
+func (m *MemoryStore) UpsertAgentStatus(serverID string, status *model.AgentStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := *status
+	s.Collectors = append([]model.CollectorStatus{}, status.Collectors...)
+	sort.Slice(s.Collectors, func(i, j int) bool {
+		return s.Collectors[i].Name < s.Collectors[j].Name
+	})
+	s.UpdatedAt = time.Now()
+	m.agentStatus[serverID] = s
+	return nil
+}
+
+func (m *MemoryStore) GetAgentStatus(serverID string) (*model.AgentStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.agentStatus[serverID]
+	if !ok {
+		return nil, nil
+	}
+	s.Collectors = append([]model.CollectorStatus{}, s.Collectors...)
+	return &s, nil
+}
+
+func (m *MemoryStore) UpsertInventory(inv *model.Inventory) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *inv
+	stored.ServerName = ""
+	stored.UpdatedAt = time.Now()
+	m.inventory[inv.ServerID] = stored
+	return nil
+}
+
+func (m *MemoryStore) GetInventory(serverID string) (*model.Inventory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inv, ok := m.inventory[serverID]
+	if !ok {
+		return nil, nil
+	}
+	inv.ServerName = m.servers[serverID].Name
+	return &inv, nil
+}
+
+func (m *MemoryStore) ListInventory(filter model.InventoryFilter) ([]model.Inventory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inventory := []model.Inventory{}
+	for id, inv := range m.inventory {
+		server, ok := m.servers[id]
+		if !ok {
+			continue
+		}
+		if (filter.Platform != "" && inv.Platform != filter.Platform) ||
+			(filter.PlatformFamily != "" && inv.PlatformFamily != filter.PlatformFamily) ||
+			(filter.PlatformVersion != "" && inv.PlatformVersion != filter.PlatformVersion) ||
+			(filter.Kernel != "" && !strings.HasPrefix(inv.KernelVersion, filter.Kernel)) ||
+			(filter.Arch != "" && inv.KernelArch != filter.Arch) {
+			continue
+		}
+		inv.ServerName = server.Name
+		inventory = append(inventory, inv)
+	}
+
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].ServerName < inventory[j].ServerName
+	})
+	return inventory, nil
+}
+
+func (m *MemoryStore) ApplyPackageReport(serverID string, report *model.PackageReport, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.packages[serverID]
+	if stored == nil {
+		stored = make(map[string]storedPackage)
+		m.packages[serverID] = stored
+	}
+
+	existing := make(map[string]model.Package, len(stored))
+	for key, p := range stored {
+		existing[key] = p.Package
+	}
+
+	upserts, removed, changes := diffPackages(serverID, existing, report, at)
+	for _, p := range upserts {
+		stored[p.Key()] = storedPackage{Package: p, UpdatedAt: at}
+	}
+	for _, p := range removed {
+		delete(stored, p.Key())
+	}
+	m.packageChanges = append(m.packageChanges, changes...)
+	return nil
+}
+
+func (m *MemoryStore) FindPackages(name string) ([]model.ServerPackage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	packages := []model.ServerPackage{}
+	for serverID, stored := range m.packages {
+		server, ok := m.servers[serverID]
+		if !ok {
+			continue
+		}
+		for _, p := range stored {
+			if p.Name != name {
+				continue
+			}
+			packages = append(packages, model.ServerPackage{
+				ServerID:   serverID,
+				ServerName: server.Name,
+				Package:    p.Package,
+				UpdatedAt:  p.UpdatedAt,
+			})
+		}
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].ServerName < packages[j].ServerName
+	})
+	return packages, nil
+}
+
+func (m *MemoryStore) GetPackages(serverID string) ([]model.Package, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	packages := []model.Package{}
+	for _, p := range m.packages[serverID] {
+		packages = append(packages, p.Package)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+	return packages, nil
+}
+
+func (m *MemoryStore) GetPackageChanges(serverID string, start, end time.Time) ([]model.PackageChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	changes := []model.PackageChange{}
+	for i := len(m.packageChanges) - 1; i >= 0; i-- {
+		ch := m.packageChanges[i]
+		if ch.ServerID == serverID && !ch.ChangedAt.Before(start) && ch.ChangedAt.Before(end) {
+			changes = append(changes, ch)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.After(changes[j].ChangedAt)
+	})
+	return changes, nil
+}
+
+func (m *MemoryStore) InsertSensorReadings(serverID string, timestamp time.Time, sensors []model.Sensor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sensor := range sensors {
+		m.sensors[serverID] = append(m.sensors[serverID], sensorReading{Timestamp: timestamp, Sensor: sensor})
+	}
+	return nil
+}
+
+func sortSensors(sensors []model.Sensor) {
+	sort.SliceStable(sensors, func(i, j int) bool {
+		if sensors[i].Kind != sensors[j].Kind {
+			return sensors[i].Kind > sensors[j].Kind
+		}
+		return sensors[i].Label < sensors[j].Label
+	})
+}
+
+func (m *MemoryStore) GetLatestSensors(serverID string) ([]model.Sensor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	readings := m.sensors[serverID]
+	sensors := []model.Sensor{}
+	if len(readings) == 0 {
+		return sensors, nil
+	}
+
+	latest := readings[len(readings)-1].Timestamp
+	for _, r := range readings {
+		if r.Timestamp.After(latest) {
+			latest = r.Timestamp
+		}
+	}
+	for _, r := range readings {
+		if r.Timestamp.Equal(latest) {
+			sensors = append(sensors, r.Sensor)
+		}
+	}
+	sortSensors(sensors)
+	return sensors, nil
+}
+
+func (m *MemoryStore) GetSensorHistory(serverID string, start, end time.Time) ([]model.SensorSeries, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var readings []sensorReading
+	for _, r := range m.sensors[serverID] {
+		if !r.Timestamp.Before(start) && r.Timestamp.Before(end) {
+			readings = append(readings, r)
+		}
+	}
+	sort.SliceStable(readings, func(i, j int) bool {
+		a, b := readings[i], readings[j]
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind
+		}
+		if a.Label != b.Label {
+			return a.Label < b.Label
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	})
+
+	series := []model.SensorSeries{}
+	for _, r := range readings {
+		n := len(series)
+		if n == 0 || series[n-1].Label != r.Label || series[n-1].Kind != r.Kind {
+			series = append(series, model.SensorSeries{Label: r.Label, Kind: r.Kind})
+			n++
+		}
+		series[n-1].Points = append(series[n-1].Points, model.SensorPoint{Timestamp: r.Timestamp, Value: r.Value})
+	}
+	return series, nil
+}
+
+func (m *MemoryStore) Prune(kind string, before time.Time, serverIDs, except []string) (int64, error) {
+	if _, ok := pruneTables[kind]; !ok {
+		return 0, fmt.Errorf("unknown data kind %q", kind)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	include := make(map[string]bool, len(serverIDs))
+	for _, id := range serverIDs {
+		include[id] = true
+	}
+	exclude := make(map[string]bool, len(except))
+	for _, id := range except {
+		exclude[id] = true
+	}
+	selected := func(id string) bool {
+		return (serverIDs == nil || include[id]) && !exclude[id]
+	}
+
+	var deleted int64
+	switch kind {
+	case "metrics":
+		for id, list := range m.metrics {
+			if !selected(id) {
+				continue
+			}
+			i := sort.Search(len(list), func(i int) bool {
+				return !list[i].Timestamp.Before(before)
+			})
+			deleted += int64(i)
+			m.metrics[id] = append([]model.Metrics(nil), list[i:]...)
+		}
+	case "sensors":
+		for id, readings := range m.sensors {
+			if !selected(id) {
+				continue
+			}
+			kept := readings[:0]
+			for _, r := range readings {
+				if !r.Timestamp.Before(before) {
+					kept = append(kept, r)
+				}
+			}
+			deleted += int64(len(readings) - len(kept))
+			m.sensors[id] = kept
+		}
+	case "package_changes":
+		kept := m.packageChanges[:0]
+		for _, ch := range m.packageChanges {
+			if selected(ch.ServerID) && ch.ChangedAt.Before(before) {
+				deleted++
+				continue
+			}
+			kept = append(kept, ch)
+		}
+		m.packageChanges = kept
+	case "anomalies":
+		kept := m.anomalies[:0]
+		for _, a := range m.anomalies {
+			if selected(a.ServerID) && a.StartedAt.Before(before) {
+				deleted++
+				continue
+			}
+			kept = append(kept, a)
+		}
+		m.anomalies = kept
+	}
+	return deleted, nil
+}
+
+func (m *MemoryStore) Vacuum(full bool) error {
+	return nil
+}
+
+// StorageStats reports row counts only; the memory store has no file size.
+func (m *MemoryStore) StorageStats() (*model.StorageStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var metrics, sensors, processes, disks, interfaces, packages int
+	for _, list := range m.metrics {
+		metrics += len(list)
+	}
+	for _, list := range m.sensors {
+		sensors += len(list)
+	}
+	for _, list := range m.processes {
+		processes += len(list)
+	}
+	for _, list := range m.disks {
+		disks += len(list)
+	}
+	for _, list := range m.interfaces {
+		interfaces += len(list)
+	}
+	for _, list := range m.packages {
+		packages += len(list)
+	}
+
+	counts := map[string]int{
+		"servers":             len(m.servers),
+		"server_labels":       len(m.labels),
+		"metrics":             metrics,
+		"server_info":         len(m.info),
+		"disks":               disks,
+		"processes":           processes,
+		"network_interfaces":  interfaces,
+		"agent_status":        len(m.agentStatus),
+		"inventory":           len(m.inventory),
+		"packages":            packages,
+		"package_changes":     len(m.packageChanges),
+		"sensor_readings":     sensors,
+		"blocked_servers":     len(m.blocked),
+		"maintenance_windows": len(m.windows),
+		"anomalies":           len(m.anomalies),
+	}
+	stats := &model.StorageStats{Driver: "memory", Tables: []model.TableStats{}}
+	for name, rows := range counts {
+		stats.Tables = append(stats.Tables, model.TableStats{Name: name, Rows: int64(rows)})
+	}
+	sort.Slice(stats.Tables, func(i, j int) bool { return stats.Tables[i].Name < stats.Tables[j].Name })
+	return stats, nil
+}
+
+// SaveReports writes the reports one by one. Unlike DB.SaveReports a failed
+// batch may be partly applied, as in-memory writes are not transactional.
+func (m *MemoryStore) SaveReports(reports []model.ReceivedReport) error {
+	for _, r := range reports {
+		if err := m.saveReport(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) saveReport(r model.ReceivedReport) error {
+	report := r.Report
+
+	blocked, err := m.IsServerBlocked(report.ServerID)
+	if err != nil || blocked {
+		return err
+	}
+	if err := m.UpsertServer(&r.Server); err != nil {
+		return err
+	}
+	if report.Labels != nil {
+		labels := make(map[string]string, len(report.Labels))
+		for k, v := range report.Labels {
+			labels[k] = v
+		}
+		m.mu.Lock()
+		m.labels[report.ServerID] = labels
+		m.mu.Unlock()
+	}
+
+	if report.Metrics != nil {
+		if err := m.InsertMetrics(report.Metrics); err != nil {
+			return err
+		}
+	}
+	if report.Info != nil {
+		if err := m.UpsertServerInfo(report.Info); err != nil {
+			return err
+		}
+	}
+	if len(report.Disks) > 0 {
+		if err := m.ReplaceDisks(report.ServerID, report.Disks); err != nil {
+			return err
+		}
+	}
+	if len(report.Processes) > 0 {
+		if err := m.ReplaceProcesses(report.ServerID, report.Processes); err != nil {
+			return err
+		}
+	}
+	if len(report.Network) > 0 {
+		if err := m.ReplaceNetworkInterfaces(report.ServerID, report.Network); err != nil {
+			return err
+		}
+	}
+	if report.Inventory != nil {
+		if err := m.UpsertInventory(report.Inventory); err != nil {
+			return err
+		}
+	}
+	if len(report.Sensors) > 0 {
+		if err := m.InsertSensorReadings(report.ServerID, report.Timestamp, report.Sensors); err != nil {
+			return err
+		}
+	}
+	if report.Packages != nil {
+		if err := m.ApplyPackageReport(report.ServerID, report.Packages, report.Timestamp); err != nil {
+			return err
+		}
+	}
+	if report.Agent != nil {
+		if err := m.UpsertAgentStatus(report.ServerID, report.Agent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
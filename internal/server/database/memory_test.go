@@ -0,0 +1,52 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+func TestMemorySaveReports(t *testing.T) {
+	m := NewMemory()
+	if err := m.BlockServer("blocked", "deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	report := func(id string) model.ReceivedReport {
+		return model.ReceivedReport{
+			Server: model.Server{ID: id, Name: id, Status: "online", LastHeartbeat: now},
+			Report: &model.AgentReport{
+				ServerID:  id,
+				Labels:    map[string]string{"env": "prod"},
+				Timestamp: now,
+				Metrics:   &model.Metrics{ServerID: id, Timestamp: now, CPU: 12},
+				Disks:     []model.Disk{{Name: "sda1", MountPoint: "/", UsagePercent: 50}},
+			},
+		}
+	}
+	if err := m.SaveReports([]model.ReceivedReport{report("a"), report("blocked")}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := m.GetServer("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"env": "prod"}; !reflect.DeepEqual(s.Labels, want) {
+		t.Errorf("labels = %v, want %v", s.Labels, want)
+	}
+	latest, err := m.GetLatestMetrics("a")
+	if err != nil || latest == nil || latest.CPU != 12 {
+		t.Errorf("GetLatestMetrics = %+v, %v", latest, err)
+	}
+	if disks, _ := m.GetDisks("a"); len(disks) != 1 {
+		t.Errorf("disks = %+v, want one", disks)
+	}
+
+	if _, err := m.GetServer("blocked"); err == nil {
+		t.Error("report from a blocked server was saved")
+	}
+}
@@ -0,0 +1,350 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Migration is one numbered schema change. Each dialect has its own SQL;
+// migrations are applied in Version order and never edited once released.
+type Migration struct {
+	Version  int
+	Name     string
+	SQLite   string
+	Postgres string
+	// SQLiteColumns are added before SQLite runs, skipping columns that
+	// already exist, since SQLite has no ADD COLUMN IF NOT EXISTS.
+	SQLiteColumns []Column
+}
+
+// Column is a column added by a migration.
+type Column struct {
+	Table string
+	Name  string
+	Type  string
+}
+
+// migrations lists every schema change. Add new entries at the end with the
+// next version number.
+//
+// Migration 1 is the schema at the point versioning was introduced: the
+// original six tables plus the agent status, inventory, package and sensor
+// tables that were added before it without migrations. It only uses
+// CREATE ... IF NOT EXISTS, so databases created by older releases adopt it
+// and gain only the tables they lack.
+var migrations = []Migration{
+	{Version: 1, Name: "baseline", SQLite: sqliteSchema, Postgres: postgresSchema},
+	{
+		Version: 2,
+		Name:    "server_list_indexes",
+		SQLite: `
+			CREATE INDEX IF NOT EXISTS idx_servers_name ON servers(name);
+			CREATE INDEX IF NOT EXISTS idx_servers_status ON servers(status);
+		`,
+		Postgres: `
+			CREATE INDEX IF NOT EXISTS idx_servers_name ON servers(name);
+			CREATE INDEX IF NOT EXISTS idx_servers_status ON servers(status);
+		`,
+	},
+	{
+		Version: 3,
+		Name:    "server_labels",
+		SQLite: `
+			CREATE TABLE IF NOT EXISTS server_labels (
+				server_id TEXT NOT NULL,
+				key TEXT NOT NULL,
+				value TEXT NOT NULL,
+				PRIMARY KEY (server_id, key),
+				FOREIGN KEY (server_id) REFERENCES servers(id)
+			);
+			CREATE INDEX IF NOT EXISTS idx_server_labels_key ON server_labels(key, value);
+		`,
+		Postgres: `
+			CREATE TABLE IF NOT EXISTS server_labels (
+				server_id TEXT NOT NULL,
+				key TEXT NOT NULL,
+				value TEXT NOT NULL,
+				PRIMARY KEY (server_id, key)
+			);
+			CREATE INDEX IF NOT EXISTS idx_server_labels_key ON server_labels(key, value);
+		`,
+	},
+	{
+		Version: 4,
+		Name:    "server_lifecycle",
+		SQLiteColumns: []Column{
+			{Table: "servers", Name: "lifecycle", Type: "TEXT NOT NULL DEFAULT 'active'"},
+		},
+		SQLite: `
+			CREATE INDEX IF NOT EXISTS idx_servers_lifecycle ON servers(lifecycle);
+			CREATE TABLE IF NOT EXISTS blocked_servers (
+				server_id TEXT PRIMARY KEY,
+				reason TEXT,
+				blocked_at DATETIME NOT NULL
+			);
+		`,
+		Postgres: `
+			ALTER TABLE servers ADD COLUMN IF NOT EXISTS lifecycle TEXT NOT NULL DEFAULT 'active';
+			CREATE INDEX IF NOT EXISTS idx_servers_lifecycle ON servers(lifecycle);
+			CREATE TABLE IF NOT EXISTS blocked_servers (
+				server_id TEXT PRIMARY KEY,
+				reason TEXT,
+				blocked_at TIMESTAMPTZ NOT NULL
+			);
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "maintenance_windows",
+		SQLite: `
+			CREATE TABLE IF NOT EXISTS maintenance_windows (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				servers TEXT NOT NULL DEFAULT '[]',
+				selector TEXT NOT NULL DEFAULT '',
+				start_at DATETIME NOT NULL,
+				end_at DATETIME NOT NULL,
+				recurrence TEXT NOT NULL DEFAULT '',
+				until_at DATETIME,
+				created_at DATETIME NOT NULL
+			);
+		`,
+		Postgres: `
+			CREATE TABLE IF NOT EXISTS maintenance_windows (
+				id BIGSERIAL PRIMARY KEY,
+				name TEXT NOT NULL,
+				servers TEXT NOT NULL DEFAULT '[]',
+				selector TEXT NOT NULL DEFAULT '',
+				start_at TIMESTAMPTZ NOT NULL,
+				end_at TIMESTAMPTZ NOT NULL,
+				recurrence TEXT NOT NULL DEFAULT '',
+				until_at TIMESTAMPTZ,
+				created_at TIMESTAMPTZ NOT NULL
+			);
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "anomalies",
+		SQLite: `
+			CREATE TABLE IF NOT EXISTS anomalies (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				server_id TEXT NOT NULL,
+				metric TEXT NOT NULL,
+				started_at DATETIME NOT NULL,
+				ended_at DATETIME NOT NULL,
+				value REAL NOT NULL,
+				expected REAL NOT NULL,
+				stddev REAL NOT NULL,
+				score REAL NOT NULL,
+				points INTEGER NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_anomalies_server_time ON anomalies(server_id, started_at);
+		`,
+		Postgres: `
+			CREATE TABLE IF NOT EXISTS anomalies (
+				id BIGSERIAL PRIMARY KEY,
+				server_id TEXT NOT NULL,
+				metric TEXT NOT NULL,
+				started_at TIMESTAMPTZ NOT NULL,
+				ended_at TIMESTAMPTZ NOT NULL,
+				value DOUBLE PRECISION NOT NULL,
+				expected DOUBLE PRECISION NOT NULL,
+				stddev DOUBLE PRECISION NOT NULL,
+				score DOUBLE PRECISION NOT NULL,
+				points INTEGER NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_anomalies_server_time ON anomalies(server_id, started_at);
+		`,
+	},
+	{
+		// SQLite 把时间存成带时区偏移的文本并按字符串比较；
+		// 新写入统一为 UTC，这里把旧数据也改写成 UTC。PostgreSQL 无需处理。
+		Version: 7,
+		Name:    "utc_timestamps",
+		SQLite: sqliteToUTC(map[string][]string{
+			"servers":         {"last_heartbeat"},
+			"metrics":         {"timestamp"},
+			"sensor_readings": {"timestamp"},
+			"package_changes": {"changed_at"},
+			"anomalies":       {"started_at", "ended_at"},
+		}),
+	},
+}
+
+// sqliteToUTC rewrites the given columns that carry a non-UTC offset.
+// datetime() drops the fractional seconds, which offsets in whole minutes
+// never change, so they are copied over from the original text.
+func sqliteToUTC(columns map[string][]string) string {
+	tables := make([]string, 0, len(columns))
+	for table := range columns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var b strings.Builder
+	for _, table := range tables {
+		for _, col := range columns[table] {
+			fmt.Fprintf(&b, "UPDATE %[1]s SET %[2]s = datetime(%[2]s) || substr(%[2]s, 20, length(%[2]s) - 25) || '+00:00' "+
+				"WHERE substr(%[2]s, -6) GLOB '[+-][0-9][0-9]:[0-9][0-9]' AND substr(%[2]s, -6) <> '+00:00';\n",
+				table, col)
+		}
+	}
+	return b.String()
+}
+
+// MigrationStatus describes whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator is implemented by stores with a versioned schema.
+type Migrator interface {
+	Migrate() ([]Migration, error)
+	MigrationStatus() ([]MigrationStatus, error)
+}
+
+var _ Migrator = (*DB)(nil)
+
+func (db *DB) ensureMigrationsTable() error {
+	timestamp := "DATETIME"
+	if db.isPostgres() {
+		timestamp = "TIMESTAMPTZ"
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at ` + timestamp + ` NOT NULL
+	)`)
+	return err
+}
+
+func (db *DB) appliedMigrations() (map[int]time.Time, error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+// Migrate applies pending migrations in order, each in its own transaction,
+// and returns the ones it applied.
+func (db *DB) Migrate() ([]Migration, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := db.applyMigration(m); err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func (db *DB) applyMigration(m Migration) error {
+	stmt := m.SQLite
+	if db.isPostgres() {
+		stmt = m.Postgres
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !db.isPostgres() {
+		for _, c := range m.SQLiteColumns {
+			if err := tx.addColumn(c); err != nil {
+				return err
+			}
+		}
+	}
+	if strings.TrimSpace(stmt) != "" {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addColumn adds c to its SQLite table unless the table already has it.
+func (tx *Tx) addColumn(c Column) error {
+	rows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, c.Table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if strings.EqualFold(name, c.Name) {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = tx.Exec(`ALTER TABLE ` + c.Table + ` ADD COLUMN ` + c.Name + ` ` + c.Type)
+	return err
+}
+
+// MigrationStatus reports every known migration and whether it is applied.
+func (db *DB) MigrationStatus() ([]MigrationStatus, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+
+	return status, nil
+}
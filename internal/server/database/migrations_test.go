@@ -0,0 +1,184 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openBaseline returns a SQLite database with the schema and some rows from
+// releases before migrations existed, without a schema_migrations table.
+func openBaseline(t *testing.T) *DB {
+	t.Helper()
+	db, err := New(filepath.Join(t.TempDir(), "monitor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	fixture, err := os.ReadFile("testdata/schema_e8b514f.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(fixture)); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func checkAllApplied(t *testing.T, db *DB) {
+	t.Helper()
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != len(migrations) {
+		t.Fatalf("status lists %d migrations, want %d", len(status), len(migrations))
+	}
+	for i, s := range status {
+		if s.Version != migrations[i].Version || s.Name != migrations[i].Name {
+			t.Errorf("status[%d] = %d %s, want %d %s", i, s.Version, s.Name, migrations[i].Version, migrations[i].Name)
+		}
+		if !s.Applied || s.AppliedAt == nil {
+			t.Errorf("migration %d (%s) not applied", s.Version, s.Name)
+		}
+	}
+}
+
+func TestMigrateBaselineTwice(t *testing.T) {
+	db := openBaseline(t)
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if s.Applied {
+			t.Fatalf("migration %d applied before Migrate", s.Version)
+		}
+	}
+
+	done, err := db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(migrations) {
+		t.Fatalf("first Migrate applied %d migrations, want %d", len(done), len(migrations))
+	}
+	checkAllApplied(t, db)
+
+	done, err = db.Migrate()
+	if err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	if len(done) != 0 {
+		t.Fatalf("second Migrate applied %d migrations, want 0", len(done))
+	}
+	checkAllApplied(t, db)
+
+	// 迁移后的表结构可以正常使用
+	var lifecycle string
+	if _, err := db.Exec(`INSERT INTO servers (id, name, ip) VALUES ('new', 'new', '')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`SELECT lifecycle FROM servers WHERE id = 'new'`).Scan(&lifecycle); err != nil {
+		t.Fatal(err)
+	}
+	if lifecycle != "active" {
+		t.Errorf("lifecycle = %q, want active", lifecycle)
+	}
+}
+
+// A database that already has a column added by a later migration, without
+// the migration being recorded, must still migrate: SQLite cannot make
+// ADD COLUMN conditional in SQL.
+func TestMigrateExistingColumn(t *testing.T) {
+	db := openBaseline(t)
+	if _, err := db.Exec(`ALTER TABLE servers ADD COLUMN lifecycle TEXT NOT NULL DEFAULT 'active'`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	checkAllApplied(t, db)
+}
+
+func TestMigrateOriginalSchema(t *testing.T) {
+	db := openBaseline(t)
+	if _, err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新增的表、列和索引
+	for _, table := range []string{"server_labels", "blocked_servers", "maintenance_windows", "anomalies",
+		"agent_status", "inventory", "packages", "package_changes", "sensor_readings"} {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("table %s missing", table)
+		}
+	}
+	for _, index := range []string{"idx_servers_name", "idx_servers_status", "idx_servers_lifecycle",
+		"idx_server_labels_key", "idx_anomalies_server_time", "idx_sensor_readings_server_time"} {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?`, index).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("index %s missing", index)
+		}
+	}
+	rows, err := db.Query(`SELECT id, lifecycle FROM servers`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var id, lifecycle string
+		if err := rows.Scan(&id, &lifecycle); err != nil {
+			t.Fatal(err)
+		}
+		if lifecycle != "active" {
+			t.Errorf("server %s: lifecycle = %q, want active", id, lifecycle)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	// 带时区偏移的时间改写为 UTC，保留小数秒
+	for _, tt := range []struct {
+		query, want string
+	}{
+		{`SELECT last_heartbeat || '' FROM servers WHERE id = 'a'`, "2024-01-15 02:00:00.123456789+00:00"},
+		{`SELECT last_heartbeat || '' FROM servers WHERE id = 'b'`, "2024-01-15 02:00:05+00:00"},
+		{`SELECT last_heartbeat || '' FROM servers WHERE id = 'c'`, "2024-01-15 02:00:10+00:00"},
+		{`SELECT MIN(timestamp) || '' FROM metrics WHERE server_id = 'a'`, "2024-01-15 01:59:55+00:00"},
+		{`SELECT MAX(timestamp) || '' FROM metrics WHERE server_id = 'a'`, "2024-01-15 02:00:00.5+00:00"},
+		{`SELECT timestamp || '' FROM metrics WHERE server_id = 'b'`, "2024-01-15 02:00:00+00:00"},
+	} {
+		var got string
+		if err := db.QueryRow(tt.query).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	// 按 UTC 的时间范围可以查到原来以其他时区写入的样本
+	start := time.Date(2024, 1, 15, 1, 59, 59, 0, time.UTC)
+	for id, want := range map[string]int{"a": 1, "b": 1, "c": 0} {
+		history, err := db.GetMetricsHistory(id, start, start.Add(2*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != want {
+			t.Errorf("server %s: %d samples in range, want %d", id, len(history), want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"math"
+	"sort"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+// latestMetrics selects the newest metrics of every server that is neither
+// archived nor offline; the metrics of offline servers are stale.
+const latestMetrics = `WITH latest AS (
+	SELECT s.id, s.name, m.cpu, m.memory, m.network_in + m.network_out AS network
+	FROM servers s
+	JOIN metrics m ON m.id = (
+		SELECT id FROM metrics WHERE server_id = s.id ORDER BY timestamp DESC LIMIT 1
+	)
+	WHERE s.lifecycle <> 'archived' AND s.status <> 'offline'
+) `
+
+// Overview aggregates the latest state of the fleet in the database, leaving
+// out archived servers. Only the small per-group results are read back.
+func (db *DB) Overview(top int, diskThreshold float64) (*model.Overview, error) {
+	o := newOverview(diskThreshold)
+
+	rows, err := db.Query(`SELECT status, COALESCE(os, ''), COALESCE(location, ''), COUNT(*)
+	                       FROM servers WHERE lifecycle <> ? GROUP BY status, os, location`,
+		model.LifecycleArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status, os, location string
+		var n int
+		if err := rows.Scan(&status, &os, &location, &n); err != nil {
+			return nil, err
+		}
+		o.Total += n
+		o.ByStatus[status] += n
+		o.ByOS[os] += n
+		o.ByLocation[location] += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, s := range []struct {
+		column  string
+		summary *model.Summary
+	}{{"cpu", &o.CPU}, {"memory", &o.Memory}} {
+		if err := db.summarize(s.column, s.summary); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range []struct {
+		column string
+		list   *[]model.ServerValue
+	}{{"cpu", &o.TopCPU}, {"memory", &o.TopMemory}, {"network", &o.TopNetwork}} {
+		if *t.list, err = db.serverValues(latestMetrics+`SELECT id, name, `+t.column+` FROM latest
+		                                     ORDER BY `+t.column+` DESC, id LIMIT ?`, top); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.TopDisk, err = db.serverValues(`SELECT s.id, s.name, MAX(d.usage_percent) AS usage
+	                                     FROM servers s JOIN disks d ON d.server_id = s.id
+	                                     WHERE s.lifecycle <> ?
+	                                     GROUP BY s.id, s.name ORDER BY usage DESC, s.id LIMIT ?`,
+		model.LifecycleArchived, top); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT s.id, s.name, d.mount_point, d.usage_percent
+	                      FROM servers s JOIN disks d ON d.server_id = s.id
+	                      WHERE s.lifecycle <> ? AND d.usage_percent >= ?
+	                      ORDER BY d.usage_percent DESC, s.id, d.mount_point`,
+		model.LifecycleArchived, diskThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d model.DiskUsage
+		if err := rows.Scan(&d.ServerID, &d.ServerName, &d.MountPoint, &d.UsagePercent); err != nil {
+			return nil, err
+		}
+		o.FullDisks = append(o.FullDisks, d)
+	}
+
+	return o, rows.Err()
+}
+
+// summarize fills s from one column of the latest metrics. The 95th
+// percentile is read with an offset query once the count is known.
+func (db *DB) summarize(column string, s *model.Summary) error {
+	err := db.QueryRow(latestMetrics+`SELECT COUNT(*), COALESCE(AVG(`+column+`), 0), COALESCE(MAX(`+column+`), 0)
+	                                  FROM latest`).Scan(&s.Count, &s.Avg, &s.Max)
+	if err != nil || s.Count == 0 {
+		return err
+	}
+	return db.QueryRow(latestMetrics+`SELECT `+column+` FROM latest ORDER BY `+column+` LIMIT 1 OFFSET ?`,
+		nearestRank(s.Count, 95)-1).Scan(&s.P95)
+}
+
+func (db *DB) serverValues(query string, args ...interface{}) ([]model.ServerValue, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []model.ServerValue{}
+	for rows.Next() {
+		var v model.ServerValue
+		if err := rows.Scan(&v.ServerID, &v.ServerName, &v.Value); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// Overview aggregates the fleet the same way as DB.Overview.
+func (m *MemoryStore) Overview(top int, diskThreshold float64) (*model.Overview, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o := newOverview(diskThreshold)
+	var cpu, memory []float64
+	for _, s := range m.servers {
+		if s.Lifecycle == model.LifecycleArchived {
+			continue
+		}
+		o.Total++
+		o.ByStatus[s.Status]++
+		o.ByOS[s.OS]++
+		o.ByLocation[s.Location]++
+
+		maxDisk := -1.0
+		for _, d := range m.disks[s.ID] {
+			maxDisk = math.Max(maxDisk, d.UsagePercent)
+			if d.UsagePercent >= diskThreshold {
+				o.FullDisks = append(o.FullDisks, model.DiskUsage{
+					ServerID: s.ID, ServerName: s.Name,
+					MountPoint: d.MountPoint, UsagePercent: d.UsagePercent,
+				})
+			}
+		}
+		if maxDisk >= 0 {
+			o.TopDisk = append(o.TopDisk, model.ServerValue{ServerID: s.ID, ServerName: s.Name, Value: maxDisk})
+		}
+
+		list := m.metrics[s.ID]
+		if len(list) == 0 || s.Status == "offline" {
+			continue
+		}
+		latest := list[len(list)-1]
+		cpu = append(cpu, latest.CPU)
+		memory = append(memory, latest.Memory)
+		o.TopCPU = append(o.TopCPU, model.ServerValue{ServerID: s.ID, ServerName: s.Name, Value: latest.CPU})
+		o.TopMemory = append(o.TopMemory, model.ServerValue{ServerID: s.ID, ServerName: s.Name, Value: latest.Memory})
+		o.TopNetwork = append(o.TopNetwork, model.ServerValue{
+			ServerID: s.ID, ServerName: s.Name, Value: latest.NetworkIn + latest.NetworkOut,
+		})
+	}
+
+	o.CPU = summarize(cpu)
+	o.Memory = summarize(memory)
+	o.TopCPU = topN(o.TopCPU, top)
+	o.TopMemory = topN(o.TopMemory, top)
+	o.TopDisk = topN(o.TopDisk, top)
+	o.TopNetwork = topN(o.TopNetwork, top)
+	sort.Slice(o.FullDisks, func(i, j int) bool {
+		a, b := o.FullDisks[i], o.FullDisks[j]
+		if a.UsagePercent != b.UsagePercent {
+			return a.UsagePercent > b.UsagePercent
+		}
+		if a.ServerID != b.ServerID {
+			return a.ServerID < b.ServerID
+		}
+		return a.MountPoint < b.MountPoint
+	})
+
+	return o, nil
+}
+
+func newOverview(diskThreshold float64) *model.Overview {
+	return &model.Overview{
+		ByStatus:      map[string]int{},
+		ByOS:          map[string]int{},
+		ByLocation:    map[string]int{},
+		TopCPU:        []model.ServerValue{},
+		TopMemory:     []model.ServerValue{},
+		TopDisk:       []model.ServerValue{},
+		TopNetwork:    []model.ServerValue{},
+		DiskThreshold: diskThreshold,
+		FullDisks:     []model.DiskUsage{},
+	}
+}
+
+func summarize(values []float64) model.Summary {
+	if len(values) == 0 {
+		return model.Summary{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+
+	return model.Summary{
+		Count: len(sorted),
+		Avg:   sum / float64(len(sorted)),
+		P95:   sorted[nearestRank(len(sorted), 95)-1],
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// nearestRank returns the 1-based rank of the p-th percentile of n sorted
+// values.
+func nearestRank(n int, p float64) int {
+	rank := int(math.Ceil(p / 100 * float64(n)))
+	if rank < 1 {
+		rank = 1
+	}
+	return rank
+}
+
+// topN keeps the n highest values, ties broken by server ID.
+func topN(values []model.ServerValue, n int) []model.ServerValue {
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Value != values[j].Value {
+			return values[i].Value > values[j].Value
+		}
+		return values[i].ServerID < values[j].ServerID
+	})
+	if len(values) > n {
+		values = values[:n]
+	}
+	return values
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+// seedOverview stores a small fleet: three reporting servers, one offline
+// and one archived.
+func seedOverview(t *testing.T, s Store) {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	servers := []model.Server{
+		{ID: "a", Name: "web-1", OS: "Ubuntu 22.04", Location: "北京", Status: "online"},
+		{ID: "b", Name: "web-2", OS: "Ubuntu 22.04", Location: "上海", Status: "warning"},
+		{ID: "c", Name: "db-1", OS: "Debian 12", Location: "北京", Status: "online"},
+		{ID: "d", Name: "old", OS: "Debian 12", Location: "北京", Status: "offline"},
+		{ID: "e", Name: "gone", OS: "CentOS 7", Location: "上海", Status: "online"},
+	}
+	latest := map[string][2]float64{"a": {80, 40}, "b": {20, 60}, "c": {50, 50}, "d": {99, 99}, "e": {99, 99}}
+	for _, srv := range servers {
+		srv.LastHeartbeat = now
+		if err := s.UpsertServer(&srv); err != nil {
+			t.Fatal(err)
+		}
+		// 旧的一条样本不应计入
+		if err := s.InsertMetrics(&model.Metrics{ServerID: srv.ID, Timestamp: now.Add(-time.Minute), CPU: 1, Memory: 1}); err != nil {
+			t.Fatal(err)
+		}
+		v := latest[srv.ID]
+		if err := s.InsertMetrics(&model.Metrics{ServerID: srv.ID, Timestamp: now, CPU: v[0], Memory: v[1], NetworkIn: v[0] / 10, NetworkOut: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	disks := map[string][]model.Disk{
+		"a": {{Name: "sda1", MountPoint: "/", UsagePercent: 95}, {Name: "sdb1", MountPoint: "/data", UsagePercent: 40}},
+		"c": {{Name: "sda1", MountPoint: "/", UsagePercent: 92}},
+		"e": {{Name: "sda1", MountPoint: "/", UsagePercent: 99}},
+	}
+	for id, list := range disks {
+		if err := s.ReplaceDisks(id, list); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetServerLifecycle("e", model.LifecycleArchived); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverview(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "monitor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &model.Overview{
+		Total:      4,
+		ByStatus:   map[string]int{"online": 2, "warning": 1, "offline": 1},
+		ByOS:       map[string]int{"Ubuntu 22.04": 2, "Debian 12": 2},
+		ByLocation: map[string]int{"北京": 3, "上海": 1},
+		CPU:        model.Summary{Count: 3, Avg: 50, P95: 80, Max: 80},
+		Memory:     model.Summary{Count: 3, Avg: 50, P95: 60, Max: 60},
+		TopCPU: []model.ServerValue{
+			{ServerID: "a", ServerName: "web-1", Value: 80},
+			{ServerID: "c", ServerName: "db-1", Value: 50},
+		},
+		TopMemory: []model.ServerValue{
+			{ServerID: "b", ServerName: "web-2", Value: 60},
+			{ServerID: "c", ServerName: "db-1", Value: 50},
+		},
+		TopDisk: []model.ServerValue{
+			{ServerID: "a", ServerName: "web-1", Value: 95},
+			{ServerID: "c", ServerName: "db-1", Value: 92},
+		},
+		TopNetwork: []model.ServerValue{
+			{ServerID: "a", ServerName: "web-1", Value: 9},
+			{ServerID: "c", ServerName: "db-1", Value: 6},
+		},
+		DiskThreshold: 90,
+		FullDisks: []model.DiskUsage{
+			{ServerID: "a", ServerName: "web-1", MountPoint: "/", UsagePercent: 95},
+			{ServerID: "c", ServerName: "db-1", MountPoint: "/", UsagePercent: 92},
+		},
+	}
+
+	for name, s := range map[string]Store{"sqlite": db, "memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			seedOverview(t, s)
+			got, err := s.Overview(2, 90)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Overview() =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
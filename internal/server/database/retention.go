@@ -0,0 +1,166 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+// pruneTables maps the data kinds that retention policies apply to onto their
+// table and timestamp column.
+var pruneTables = map[string]struct{ table, column string }{
+	"metrics":         {"metrics", "timestamp"},
+	"sensors":         {"sensor_readings", "timestamp"},
+	"package_changes": {"package_changes", "changed_at"},
+	"anomalies":       {"anomalies", "started_at"},
+}
+
+// DataKinds returns the data kinds that can be pruned, sorted.
+func DataKinds() []string {
+	kinds := make([]string, 0, len(pruneTables))
+	for kind := range pruneTables {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func placeholders(n int) string {
+	return "?" + strings.Repeat(", ?", n-1)
+}
+
+// Prune deletes rows of kind older than before and returns how many were
+// removed. A nil serverIDs prunes every server; otherwise only the listed
+// ones. Servers in except are never pruned.
+func (db *DB) Prune(kind string, before time.Time, serverIDs, except []string) (int64, error) {
+	t, ok := pruneTables[kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown data kind %q", kind)
+	}
+	if serverIDs != nil && len(serverIDs) == 0 {
+		return 0, nil
+	}
+
+	query := `DELETE FROM ` + t.table + ` WHERE ` + t.column + ` < ?`
+	args := []interface{}{before}
+	if serverIDs != nil {
+		query += ` AND server_id IN (` + placeholders(len(serverIDs)) + `)`
+		for _, id := range serverIDs {
+			args = append(args, id)
+		}
+	}
+	if len(except) > 0 {
+		query += ` AND server_id NOT IN (` + placeholders(len(except)) + `)`
+		for _, id := range except {
+			args = append(args, id)
+		}
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Vacuum returns free pages to the file system. For SQLite the incremental
+// mode converts the database to auto_vacuum=INCREMENTAL once (a full VACUUM)
+// and afterwards only releases the free list; full always rebuilds the file.
+func (db *DB) Vacuum(full bool) error {
+	if db.isPostgres() {
+		if !full {
+			return nil // 由 autovacuum 负责
+		}
+		_, err := db.Exec(`VACUUM ANALYZE`)
+		return err
+	}
+
+	if full {
+		_, err := db.Exec(`VACUUM`)
+		return err
+	}
+
+	var mode int
+	if err := db.QueryRow(`PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		return err
+	}
+	if mode != 2 {
+		if _, err := db.Exec(`PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+			return err
+		}
+		_, err := db.Exec(`VACUUM`)
+		return err
+	}
+	_, err := db.Exec(`PRAGMA incremental_vacuum`)
+	return err
+}
+
+// StorageStats reports the database size and the row count of every table.
+func (db *DB) StorageStats() (*model.StorageStats, error) {
+	stats := &model.StorageStats{Driver: db.driver, Tables: []model.TableStats{}}
+
+	listQuery := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	if db.isPostgres() {
+		listQuery = `SELECT tablename FROM pg_tables WHERE schemaname = current_schema() ORDER BY tablename`
+	}
+	names, err := db.queryStrings(listQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		t := model.TableStats{Name: name}
+		// 表名来自系统目录，可以安全拼接
+		if err := db.QueryRow(`SELECT COUNT(*) FROM "` + name + `"`).Scan(&t.Rows); err != nil {
+			return nil, err
+		}
+		if db.isPostgres() {
+			var size int64
+			if err := db.QueryRow(`SELECT pg_total_relation_size(?::regclass)`, name).Scan(&size); err != nil {
+				return nil, err
+			}
+			t.SizeBytes = &size
+		}
+		stats.Tables = append(stats.Tables, t)
+	}
+
+	if db.isPostgres() {
+		err = db.QueryRow(`SELECT pg_database_size(current_database())`).Scan(&stats.SizeBytes)
+		return stats, err
+	}
+
+	var pageSize, pageCount, freePages int64
+	if err := db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return nil, err
+	}
+	if err := db.QueryRow(`PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return nil, err
+	}
+	if err := db.QueryRow(`PRAGMA freelist_count`).Scan(&freePages); err != nil {
+		return nil, err
+	}
+	stats.SizeBytes = pageSize * pageCount
+	stats.FreeBytes = pageSize * freePages
+	return stats, nil
+}
+
+func (db *DB) queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// New opens the SQLite database at dbPath.
+func New(dbPath string) (*DB, error) {
+	return open("sqlite3", dbPath)
+}
+
+// sqliteSchema is the baseline schema (migration 1).
+const sqliteSchema = `
+	CREATE TABLE IF NOT EXISTS servers (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		os TEXT,
+		location TEXT,
+		status TEXT DEFAULT 'offline',
+		last_heartbeat DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS metrics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		cpu REAL,
+		memory REAL,
+		disk_read REAL,
+		disk_write REAL,
+		network_in REAL,
+		network_out REAL,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_metrics_server_time ON metrics(server_id, timestamp DESC);
+
+	CREATE TABLE IF NOT EXISTS server_info (
+		server_id TEXT PRIMARY KEY,
+		cpu_cores INTEGER,
+		total_memory INTEGER,
+		used_memory INTEGER,
+		uptime INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS disks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		name TEXT,
+		mount_point TEXT,
+		fs_type TEXT,
+		total_size INTEGER,
+		used_size INTEGER,
+		available_size INTEGER,
+		usage_percent REAL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS processes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		pid INTEGER,
+		name TEXT,
+		cpu REAL,
+		memory REAL,
+		username TEXT,
+		status TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_processes_server ON processes(server_id);
+
+	CREATE TABLE IF NOT EXISTS network_interfaces (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		name TEXT,
+		type TEXT,
+		upload_speed REAL,
+		download_speed REAL,
+		total_upload INTEGER,
+		total_download INTEGER,
+		status TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_status (
+		server_id TEXT PRIMARY KEY,
+		version TEXT,
+		started_at DATETIME,
+		rss INTEGER,
+		cpu REAL,
+		goroutines INTEGER,
+		queue_depth INTEGER,
+		reports_sent INTEGER,
+		reports_failed INTEGER,
+		last_success DATETIME,
+		last_error TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS agent_collectors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		name TEXT,
+		last_run DATETIME,
+		last_duration REAL,
+		successes INTEGER,
+		failures INTEGER,
+		last_error TEXT,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_agent_collectors_server ON agent_collectors(server_id);
+
+	CREATE TABLE IF NOT EXISTS inventory (
+		server_id TEXT PRIMARY KEY,
+		hostname TEXT,
+		os TEXT,
+		platform TEXT,
+		platform_family TEXT,
+		platform_version TEXT,
+		kernel_version TEXT,
+		kernel_arch TEXT,
+		virtualization_system TEXT,
+		virtualization_role TEXT,
+		boot_time DATETIME,
+		cpu_model TEXT,
+		cpu_cores INTEGER,
+		total_memory INTEGER,
+		total_disk INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS packages (
+		server_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		name TEXT NOT NULL,
+		arch TEXT NOT NULL DEFAULT '',
+		version TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (server_id, source, name, arch),
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_packages_name ON packages(name);
+
+	CREATE TABLE IF NOT EXISTS package_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		source TEXT,
+		name TEXT,
+		arch TEXT,
+		action TEXT,
+		old_version TEXT,
+		new_version TEXT,
+		changed_at DATETIME NOT NULL,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_package_changes_server_time ON package_changes(server_id, changed_at DESC);
+
+	CREATE TABLE IF NOT EXISTS sensor_readings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		label TEXT,
+		kind TEXT,
+		value REAL,
+		high REAL,
+		critical REAL,
+		FOREIGN KEY (server_id) REFERENCES servers(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sensor_readings_server_time ON sensor_readings(server_id, timestamp DESC);
+`
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/pkgversion"
+)
+
+// Store is the storage backend used by the API server.
+type Store interface {
+	Initialize() error
+	Close() error
+
+	UpsertServer(server *model.Server) error
+	GetServers() ([]model.Server, error)
+	ListServers(q model.ServerQuery) ([]model.ServerListItem, int, error)
+	GetServer(id string) (*model.Server, error)
+	DeleteServer(id string) error
+	SetServerLifecycle(id, lifecycle string) error
+	ArchiveStaleServers(before time.Time) (int64, error)
+	BlockServer(id, reason string) error
+	UnblockServer(id string) error
+	IsServerBlocked(id string) (bool, error)
+	GetBlockedServers() ([]model.BlockedServer, error)
+
+	CreateMaintenanceWindow(w *model.MaintenanceWindow) error
+	GetMaintenanceWindows() ([]model.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(id int64) error
+
+	SaveAnomaly(a *model.Anomaly) error
+	GetAnomalies(serverID string, start, end time.Time) ([]model.Anomaly, error)
+	// Overview aggregates the latest state of the fleet, leaving out
+	// archived servers; CPU, memory and network only cover servers that are
+	// not offline.
+	Overview(top int, diskThreshold float64) (*model.Overview, error)
+	// UpdateServerStatus derives each server's status from its heartbeat.
+	// Servers in maintenance, by lifecycle or because they are listed in
+	// maintenance, get the maintenance status instead.
+	UpdateServerStatus(maintenance []string) error
+
+	InsertMetrics(metrics *model.Metrics) error
+	GetLatestMetrics(serverID string) (*model.Metrics, error)
+	GetMetricsHistory(serverID string, start, end time.Time) ([]model.Metrics, error)
+	GetMetricsRange(serverIDs []string, start, end time.Time) (map[string][]model.Metrics, error)
+	EachMetric(serverIDs []string, start, end time.Time, fn func(m *model.Metrics) error) error
+
+	UpsertServerInfo(info *model.ServerInfo) error
+	GetServerInfo(serverID string) (*model.ServerInfo, error)
+
+	ReplaceDisks(serverID string, disks []model.Disk) error
+	GetDisks(serverID string) ([]model.Disk, error)
+	GetAllDisks() (map[string][]model.Disk, error)
+	ReplaceProcesses(serverID string, processes []model.Process) error
+	GetProcesses(serverID string, sortBy string, limit int) ([]model.Process, error)
+	ReplaceNetworkInterfaces(serverID string, interfaces []model.NetworkInterface) error
+	GetNetworkInterfaces(serverID string) ([]model.NetworkInterface, error)
+
+	UpsertAgentStatus(serverID string, status *model.AgentStatus) error
+	GetAgentStatus(serverID string) (*model.AgentStatus, error)
+
+	UpsertInventory(inv *model.Inventory) error
+	GetInventory(serverID string) (*model.Inventory, error)
+	ListInventory(filter model.InventoryFilter) ([]model.Inventory, error)
+
+	ApplyPackageReport(serverID string, report *model.PackageReport, at time.Time) error
+	FindPackages(name string) ([]model.ServerPackage, error)
+	GetPackages(serverID string) ([]model.Package, error)
+	GetPackageChanges(serverID string, start, end time.Time) ([]model.PackageChange, error)
+
+	InsertSensorReadings(serverID string, timestamp time.Time, sensors []model.Sensor) error
+	GetLatestSensors(serverID string) ([]model.Sensor, error)
+	GetSensorHistory(serverID string, start, end time.Time) ([]model.SensorSeries, error)
+
+	// SaveReports writes a batch of agent reports atomically.
+	SaveReports(reports []model.ReceivedReport) error
+
+	Prune(kind string, before time.Time, serverIDs, except []string) (int64, error)
+	Vacuum(full bool) error
+	StorageStats() (*model.StorageStats, error)
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// Open returns the Store for driver: "sqlite" (default), "postgres" or
+// "memory". source is the SQLite file path or the PostgreSQL DSN.
+func Open(driver, source string) (Store, error) {
+	switch driver {
+	case "", "sqlite", "sqlite3":
+		return New(source)
+	case "postgres", "postgresql":
+		return NewPostgres(source)
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// diffPackages works out how a package report changes the stored set. The
+// first full list for a server is the baseline and records no changes.
+func diffPackages(serverID string, existing map[string]model.Package, report *model.PackageReport, at time.Time) (upserts, removed []model.Package, changes []model.PackageChange) {
+	baseline := len(existing) == 0 && report.Full
+
+	for _, p := range report.Upserted {
+		old, ok := existing[p.Key()]
+		if ok && old.Version == p.Version {
+			continue
+		}
+		upserts = append(upserts, p)
+		if baseline {
+			continue
+		}
+
+		action := "installed"
+		if ok {
+			action = "upgraded"
+			if pkgversion.Compare(p.Version, old.Version) < 0 {
+				action = "downgraded"
+			}
+		}
+		changes = append(changes, model.PackageChange{
+			ServerID: serverID, Name: p.Name, Arch: p.Arch, Source: p.Source,
+			Action: action, OldVersion: old.Version, NewVersion: p.Version, ChangedAt: at,
+		})
+	}
+
+	candidates := report.Removed
+	if report.Full {
+		// 完整列表中不存在的软件包视为已卸载
+		incoming := make(map[string]bool, len(report.Upserted))
+		for _, p := range report.Upserted {
+			incoming[p.Key()] = true
+		}
+		candidates = nil
+		for key, p := range existing {
+			if !incoming[key] {
+				candidates = append(candidates, p)
+			}
+		}
+	}
+
+	for _, p := range candidates {
+		old, ok := existing[p.Key()]
+		if !ok {
+			continue
+		}
+		removed = append(removed, old)
+		changes = append(changes, model.PackageChange{
+			ServerID: serverID, Name: old.Name, Arch: old.Arch, Source: old.Source,
+			Action: "removed", OldVersion: old.Version, ChangedAt: at,
+		})
+	}
+
+	return upserts, removed, changes
+}
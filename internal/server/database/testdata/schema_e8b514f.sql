@@ -0,0 +1,93 @@
+-- Schema created by releases before migrations existed (commit e8b514f),
+-- with rows written in the agents' own time zones.
+
+CREATE TABLE IF NOT EXISTS servers (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	os TEXT,
+	location TEXT,
+	status TEXT DEFAULT 'offline',
+	last_heartbeat DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS metrics (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	server_id TEXT NOT NULL,
+	timestamp DATETIME NOT NULL,
+	cpu REAL,
+	memory REAL,
+	disk_read REAL,
+	disk_write REAL,
+	network_in REAL,
+	network_out REAL,
+	FOREIGN KEY (server_id) REFERENCES servers(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_server_time ON metrics(server_id, timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS server_info (
+	server_id TEXT PRIMARY KEY,
+	cpu_cores INTEGER,
+	total_memory INTEGER,
+	used_memory INTEGER,
+	uptime INTEGER,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (server_id) REFERENCES servers(id)
+);
+
+CREATE TABLE IF NOT EXISTS disks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	server_id TEXT NOT NULL,
+	name TEXT,
+	mount_point TEXT,
+	fs_type TEXT,
+	total_size INTEGER,
+	used_size INTEGER,
+	available_size INTEGER,
+	usage_percent REAL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (server_id) REFERENCES servers(id)
+);
+
+CREATE TABLE IF NOT EXISTS processes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	server_id TEXT NOT NULL,
+	pid INTEGER,
+	name TEXT,
+	cpu REAL,
+	memory REAL,
+	username TEXT,
+	status TEXT,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (server_id) REFERENCES servers(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processes_server ON processes(server_id);
+
+CREATE TABLE IF NOT EXISTS network_interfaces (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	server_id TEXT NOT NULL,
+	name TEXT,
+	type TEXT,
+	upload_speed REAL,
+	download_speed REAL,
+	total_upload INTEGER,
+	total_download INTEGER,
+	status TEXT,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (server_id) REFERENCES servers(id)
+);
+
+INSERT INTO servers (id, name, ip, os, location, status, last_heartbeat)
+VALUES ('a', 'web-1', '10.0.0.1', 'linux', '北京', 'online', '2024-01-15 10:00:00.123456789+08:00'),
+       ('b', 'web-2', '10.0.0.2', 'linux', '纽约', 'online', '2024-01-14 21:00:05-05:00'),
+       ('c', 'db-1', '10.0.0.3', 'linux', '伦敦', 'offline', '2024-01-15 02:00:10+00:00');
+
+INSERT INTO metrics (server_id, timestamp, cpu, memory, disk_read, disk_write, network_in, network_out)
+VALUES ('a', '2024-01-15 09:59:55+08:00', 10, 20, 0, 0, 0, 0),
+       ('a', '2024-01-15 10:00:00.5+08:00', 11, 21, 0, 0, 0, 0),
+       ('b', '2024-01-14 21:00:00-05:00', 30, 40, 0, 0, 0, 0),
+       ('c', '2024-01-15 02:00:10+00:00', 50, 60, 0, 0, 0, 0);
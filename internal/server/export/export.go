@@ -0,0 +1,95 @@
+// Package export writes metric rows as CSV or newline-delimited JSON.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Writer encodes metric rows one at a time. Call Flush when done.
+type Writer interface {
+	Write(m *model.Metrics) error
+	Flush() error
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// New returns a Writer for format. names maps server IDs to display names.
+func New(format string, w io.Writer, names map[string]string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write([]string{"server_id", "server_name", "timestamp", "cpu", "memory",
+			"disk_read", "disk_write", "network_in", "network_out"})
+		if err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw, names: names}, nil
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{w: bw, enc: json.NewEncoder(bw), names: names}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q, use csv or ndjson", format)
+}
+
+type csvWriter struct {
+	w     *csv.Writer
+	names map[string]string
+	row   [9]string
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (c *csvWriter) Write(m *model.Metrics) error {
+	c.row = [9]string{
+		m.ServerID, c.names[m.ServerID], m.Timestamp.UTC().Format(time.RFC3339Nano),
+		formatFloat(m.CPU), formatFloat(m.Memory), formatFloat(m.DiskRead),
+		formatFloat(m.DiskWrite), formatFloat(m.NetworkIn), formatFloat(m.NetworkOut),
+	}
+	return c.w.Write(c.row[:])
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	names map[string]string
+}
+
+type ndjsonRow struct {
+	ServerName string `json:"serverName,omitempty"`
+	*model.Metrics
+}
+
+func (n *ndjsonWriter) Write(m *model.Metrics) error {
+	// Encode 会在每行末尾追加换行
+	return n.enc.Encode(ndjsonRow{ServerName: n.names[m.ServerID], Metrics: m})
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.w.Flush()
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/pkg/api"
+)
+
+func (h *Handler) requireBackups(c *gin.Context) bool {
+	if h.backups == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Backups are only supported with the sqlite driver"})
+		return false
+	}
+	return true
+}
+
+// CreateBackup takes an online snapshot of the database.
+func (h *Handler) CreateBackup(c *gin.Context) {
+	if !h.requireBackups(c) {
+		return
+	}
+
+	info, err := h.backups.Create()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.BackupResponse{Backup: info})
+}
+
+func (h *Handler) ListBackups(c *gin.Context) {
+	if !h.requireBackups(c) {
+		return
+	}
+
+	backups, err := h.backups.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.BackupsResponse{Backups: backups})
+}
+
+func (h *Handler) DownloadBackup(c *gin.Context) {
+	if !h.requireBackups(c) {
+		return
+	}
+
+	name := c.Param("name")
+	path, err := h.backups.Path(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, name)
+}
+
+// GetStorage reports the database size per table together with the retention
+// policy and the result of the last cleanup.
+func (h *Handler) GetStorage(c *gin.Context) {
+	stats, err := h.db.StorageStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.StorageResponse{
+		Storage:     stats,
+		Retention:   h.retention.Policy(),
+		LastCleanup: h.retention.LastResult(),
+	})
+}
+
+// RunCleanup applies the retention policy immediately.
+func (h *Handler) RunCleanup(c *gin.Context) {
+	result := h.retention.Run()
+	if result.Error != "" {
+		c.JSON(http.StatusInternalServerError, api.CleanupResponse{Cleanup: result, Error: result.Error})
+		return
+	}
+	c.JSON(http.StatusOK, api.CleanupResponse{Cleanup: result})
+}
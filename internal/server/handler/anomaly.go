@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/pkg/api"
+)
+
+// GetAnomalies returns the anomalies of a server in a time range, optionally
+// filtered by metric.
+func (h *Handler) GetAnomalies(c *gin.Context) {
+	serverID := c.Param("id")
+
+	start, end, err := parseTimeRange(c, 7*24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	anomalies, err := h.db.GetAnomalies(serverID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if metric := c.Query("metric"); metric != "" {
+		filtered := []model.Anomaly{}
+		for _, a := range anomalies {
+			if a.Metric == metric {
+				filtered = append(filtered, a)
+			}
+		}
+		anomalies = filtered
+	}
+
+	c.JSON(http.StatusOK, api.AnomaliesResponse{Anomalies: anomalies, Start: start, End: end})
+}
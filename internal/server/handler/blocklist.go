@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+)
+
+// blocklistTTL bounds how long blocks made outside this process, e.g. by
+// another server sharing the database, go unnoticed on the report path.
+// Saving a report re-checks the blocklist in its transaction either way.
+const blocklistTTL = time.Minute
+
+// blocklist caches the blocked server IDs so agent reports do not need a
+// database round trip each.
+type blocklist struct {
+	db     database.Store
+	mu     sync.RWMutex
+	ids    map[string]bool
+	loaded time.Time
+}
+
+func newBlocklist(db database.Store) *blocklist {
+	return &blocklist{db: db}
+}
+
+// contains reports whether id is blocked, reloading the list once it is
+// older than blocklistTTL.
+func (b *blocklist) contains(id string) (bool, error) {
+	b.mu.RLock()
+	if b.ids != nil && time.Since(b.loaded) < blocklistTTL {
+		blocked := b.ids[id]
+		b.mu.RUnlock()
+		return blocked, nil
+	}
+	b.mu.RUnlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ids == nil || time.Since(b.loaded) >= blocklistTTL {
+		blocked, err := b.db.GetBlockedServers()
+		if err != nil {
+			return false, err
+		}
+		b.ids = make(map[string]bool, len(blocked))
+		for _, s := range blocked {
+			b.ids[s.ServerID] = true
+		}
+		b.loaded = time.Now()
+	}
+	return b.ids[id], nil
+}
+
+// set records a change made through this server.
+func (b *blocklist) set(id string, blocked bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ids == nil {
+		return // 尚未加载，下次读取时会从数据库加载
+	}
+	if blocked {
+		b.ids[id] = true
+	} else {
+		delete(b.ids, id)
+	}
+}
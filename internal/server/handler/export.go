@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/export"
+	"github.com/monitor-system/internal/server/model"
+)
+
+// exportFlushRows is how many rows are written between flushes to the client.
+const exportFlushRows = 1000
+
+// ExportMetrics streams raw metrics for the selected servers (all servers
+// when neither servers nor selector is given) as CSV or NDJSON. Rows are
+// written as they are read from the database, so large ranges are never held
+// in memory.
+func (h *Handler) ExportMetrics(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatNDJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, must be csv or ndjson"})
+		return
+	}
+
+	start, end, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 导出全部服务器时不按 ID 过滤，避免超出 SQLite 的参数数量限制
+	all := c.Query("servers") == "" && strings.TrimSpace(c.Query("selector")) == ""
+	var servers []model.Server
+	if all {
+		if servers, err = h.db.GetServers(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else if servers, err = h.selectServers(c.Query("servers"), c.Query("selector")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := make(map[string]string, len(servers))
+	var ids []string
+	for _, s := range servers {
+		names[s.ID] = s.Name
+		if !all {
+			ids = append(ids, s.ID)
+		}
+	}
+
+	filename := fmt.Sprintf("metrics-%s-%s.%s",
+		start.UTC().Format("20060102T150405Z"), end.UTC().Format("20060102T150405Z"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w, err := export.New(format, c.Writer, names)
+	if err != nil {
+		log.Printf("Export failed: %v", err)
+		return
+	}
+	if len(servers) == 0 {
+		// 没有匹配的服务器，只输出表头
+		w.Flush()
+		return
+	}
+
+	rows := 0
+	err = h.db.EachMetric(ids, start, end, func(m *model.Metrics) error {
+		if err := w.Write(m); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		// 响应头已发送，只能记录日志并中断输出
+		log.Printf("Export failed after %d rows: %v", rows, err)
+		return
+	}
+	c.Writer.Flush()
+}
@@ -1,59 +1,65 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/backup"
 	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/ingest"
 	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/pkgversion"
+	"github.com/monitor-system/internal/server/query"
+	"github.com/monitor-system/internal/server/report"
+	"github.com/monitor-system/internal/server/retention"
+	"github.com/monitor-system/internal/server/slo"
+	"github.com/monitor-system/pkg/api"
 )
 
 type Handler struct {
-	db *database.DB
+	db        database.Store
+	queue     *ingest.Queue
+	backups   *backup.Manager // 非 SQLite 存储时为 nil
+	retention *retention.Manager
+	slo       *slo.Reporter
+	reports   *report.Manager
+	blocked   *blocklist
 }
 
-func New(db *database.DB) *Handler {
-	return &Handler{db: db}
+func New(db database.Store, queue *ingest.Queue, backups *backup.Manager, retention *retention.Manager,
+	slo *slo.Reporter, reports *report.Manager) *Handler {
+	return &Handler{db: db, queue: queue, backups: backups, retention: retention, slo: slo, reports: reports,
+		blocked: newBlocklist(db)}
 }
 
 func (h *Handler) VerifyAuth(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "验证成功"})
+	c.JSON(http.StatusOK, api.SuccessResponse{Success: true, Message: "验证成功"})
 }
 
 func (h *Handler) GetServers(c *gin.Context) {
-	servers, err := h.db.GetServers()
+	q, err := parseServerQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, total, err := h.db.ListServers(q)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Add current metrics for each server
-	type ServerWithMetrics struct {
-		model.Server
-		CurrentMetrics *struct {
-			CPU      float64 `json:"cpu"`
-			Memory   float64 `json:"memory"`
-			Network  float64 `json:"network"`
-			Upload   float64 `json:"upload"`   // 上行速度 (MB/s)
-			Download float64 `json:"download"` // 下行速度 (MB/s)
-		} `json:"currentMetrics,omitempty"`
-	}
-
-	result := make([]ServerWithMetrics, 0, len(servers))
-	for _, server := range servers {
-		serverMetrics := ServerWithMetrics{Server: server}
-
-		metrics, err := h.db.GetLatestMetrics(server.ID)
-		if err == nil && metrics != nil {
-			serverMetrics.CurrentMetrics = &struct {
-				CPU      float64 `json:"cpu"`
-				Memory   float64 `json:"memory"`
-				Network  float64 `json:"network"`
-				Upload   float64 `json:"upload"`
-				Download float64 `json:"download"`
-			}{
+	result := make([]api.ServerListItem, 0, len(items))
+	for _, item := range items {
+		serverMetrics := api.ServerListItem{Server: item.Server}
+
+		if metrics := item.Metrics; metrics != nil {
+			serverMetrics.CurrentMetrics = &api.CurrentMetrics{
 				CPU:      metrics.CPU,
 				Memory:   metrics.Memory,
 				Network:  (metrics.NetworkIn + metrics.NetworkOut) / 2,
@@ -65,7 +71,57 @@ func (h *Handler) GetServers(c *gin.Context) {
 		result = append(result, serverMetrics)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"servers": result})
+	c.JSON(http.StatusOK, api.ServerListResponse{
+		Servers: result,
+		Total:   total,
+		Limit:   q.Limit,
+		Offset:  q.Offset,
+	})
+}
+
+// parseServerQuery reads search, status, sort, order, limit and offset.
+func parseServerQuery(c *gin.Context) (model.ServerQuery, error) {
+	q := model.ServerQuery{
+		Search:    strings.TrimSpace(c.Query("search")),
+		Status:    c.Query("status"),
+		Lifecycle: c.Query("lifecycle"),
+		Sort:      c.DefaultQuery("sort", "name"),
+	}
+
+	switch q.Lifecycle {
+	case "", "all", model.LifecycleActive, model.LifecycleMaintenance, model.LifecycleArchived:
+	default:
+		return q, fmt.Errorf("invalid lifecycle, must be active, maintenance, archived or all")
+	}
+
+	valid := false
+	for _, field := range model.ServerSortFields {
+		if q.Sort == field {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return q, fmt.Errorf("invalid sort, must be one of: %s", strings.Join(model.ServerSortFields, ", "))
+	}
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("invalid order, must be asc or desc")
+	}
+
+	var err error
+	if q.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0")); err != nil || q.Limit < 0 {
+		return q, fmt.Errorf("invalid limit")
+	}
+	if q.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil || q.Offset < 0 {
+		return q, fmt.Errorf("invalid offset")
+	}
+
+	return q, nil
 }
 
 func (h *Handler) GetServerDetail(c *gin.Context) {
@@ -79,58 +135,73 @@ func (h *Handler) GetServerDetail(c *gin.Context) {
 
 	metrics, _ := h.db.GetLatestMetrics(serverID)
 	info, _ := h.db.GetServerInfo(serverID)
-
-	response := gin.H{
-		"server": gin.H{
-			"id":       server.ID,
-			"name":     server.Name,
-			"ip":       server.IP,
-			"status":   server.Status,
-			"os":       server.OS,
-			"location": server.Location,
-		},
+	inventory, _ := h.db.GetInventory(serverID)
+
+	detail := api.ServerDetail{
+		ID:        server.ID,
+		Name:      server.Name,
+		IP:        server.IP,
+		Status:    server.Status,
+		OS:        server.OS,
+		Location:  server.Location,
+		Inventory: inventory,
 	}
 
 	if metrics != nil {
-		response["server"].(gin.H)["metrics"] = gin.H{
-			"cpu":        metrics.CPU,
-			"memory":     metrics.Memory,
-			"diskRead":   metrics.DiskRead,
-			"diskWrite":  metrics.DiskWrite,
-			"networkIn":  metrics.NetworkIn,
-			"networkOut": metrics.NetworkOut,
+		detail.Metrics = &api.LatestMetrics{
+			CPU:        metrics.CPU,
+			Memory:     metrics.Memory,
+			DiskRead:   metrics.DiskRead,
+			DiskWrite:  metrics.DiskWrite,
+			NetworkIn:  metrics.NetworkIn,
+			NetworkOut: metrics.NetworkOut,
 		}
 	}
 
 	if info != nil {
-		response["server"].(gin.H)["info"] = gin.H{
-			"cpuCores":    info.CPUCores,
-			"totalMemory": info.TotalMemory,
-			"usedMemory":  info.UsedMemory,
-			"uptime":      info.Uptime,
+		detail.Info = &api.ServerDetailInfo{
+			CPUCores:    info.CPUCores,
+			TotalMemory: info.TotalMemory,
+			UsedMemory:  info.UsedMemory,
+			Uptime:      info.Uptime,
 		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, api.ServerDetailResponse{Server: detail})
 }
 
 func (h *Handler) GetHistory(c *gin.Context) {
 	serverID := c.Param("id")
-	durationStr := c.DefaultQuery("duration", "20m")
 
-	duration, err := time.ParseDuration(durationStr)
+	start, end, err := parseTimeRange(c, 20*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	step, err := historyStep(c, start, end, maxQueryPoints)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	history, err := h.db.GetMetricsHistory(serverID, duration)
+	history, err := h.db.GetMetricsHistory(serverID, start, end)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"history": history})
+	// 数据点过多时按时间桶取平均
+	resolution := query.Resolution(start, end, step, len(history), maxHistoryPoints)
+	if resolution > 0 {
+		history = query.DownsampleMetrics(history, query.Buckets(start, end, resolution), resolution)
+	}
+
+	c.JSON(http.StatusOK, api.HistoryResponse{
+		History:    history,
+		Start:      start,
+		End:        end,
+		Resolution: int64(resolution / time.Second),
+	})
 }
 
 func (h *Handler) GetDisks(c *gin.Context) {
@@ -142,7 +213,7 @@ func (h *Handler) GetDisks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"disks": disks})
+	c.JSON(http.StatusOK, api.DisksResponse{Disks: disks})
 }
 
 func (h *Handler) GetProcesses(c *gin.Context) {
@@ -161,7 +232,7 @@ func (h *Handler) GetProcesses(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"processes": processes})
+	c.JSON(http.StatusOK, api.ProcessesResponse{Processes: processes})
 }
 
 func (h *Handler) GetNetwork(c *gin.Context) {
@@ -173,7 +244,155 @@ func (h *Handler) GetNetwork(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"interfaces": interfaces})
+	c.JSON(http.StatusOK, api.NetworkResponse{Interfaces: interfaces})
+}
+
+func (h *Handler) GetAgentStatus(c *gin.Context) {
+	serverID := c.Param("id")
+
+	status, err := h.db.GetAgentStatus(serverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if status == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent status not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.AgentStatusResponse{Agent: status})
+}
+
+func (h *Handler) GetInventory(c *gin.Context) {
+	filter := model.InventoryFilter{
+		Platform:        c.Query("platform"),
+		PlatformFamily:  c.Query("platformFamily"),
+		PlatformVersion: c.Query("platformVersion"),
+		Kernel:          c.Query("kernel"),
+		Arch:            c.Query("arch"),
+	}
+
+	inventory, err := h.db.ListInventory(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.InventoryResponse{Inventory: inventory})
+}
+
+func (h *Handler) GetSensors(c *gin.Context) {
+	serverID := c.Param("id")
+
+	start, end, err := parseTimeRange(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	step, err := historyStep(c, start, end, maxQueryPoints)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	latest, err := h.db.GetLatestSensors(serverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := h.db.GetSensorHistory(serverID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	longest := 0
+	for _, series := range history {
+		if len(series.Points) > longest {
+			longest = len(series.Points)
+		}
+	}
+	resolution := query.Resolution(start, end, step, longest, maxHistoryPoints)
+	if resolution > 0 {
+		buckets := query.Buckets(start, end, resolution)
+		for i := range history {
+			history[i].Points = query.DownsamplePoints(history[i].Points, buckets, resolution)
+		}
+	}
+
+	c.JSON(http.StatusOK, api.SensorsResponse{
+		Sensors:    latest,
+		History:    history,
+		Start:      start,
+		End:        end,
+		Resolution: int64(resolution / time.Second),
+	})
+}
+
+// SearchPackages answers "which servers have package X", optionally limited by
+// a version constraint such as version=<3.0.2.
+func (h *Handler) SearchPackages(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	var constraint *pkgversion.Constraint
+	if expr := c.Query("version"); expr != "" {
+		parsed, err := pkgversion.ParseConstraint(expr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version constraint"})
+			return
+		}
+		constraint = &parsed
+	}
+
+	packages, err := h.db.FindPackages(name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]model.ServerPackage, 0, len(packages))
+	for _, p := range packages {
+		if constraint == nil || constraint.Matches(p.Version) {
+			result = append(result, p)
+		}
+	}
+
+	c.JSON(http.StatusOK, api.PackageSearchResponse{Packages: result})
+}
+
+func (h *Handler) GetPackages(c *gin.Context) {
+	serverID := c.Param("id")
+
+	packages, err := h.db.GetPackages(serverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.PackagesResponse{Packages: packages})
+}
+
+func (h *Handler) GetPackageHistory(c *gin.Context) {
+	serverID := c.Param("id")
+
+	start, end, err := parseTimeRange(c, 720*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes, err := h.db.GetPackageChanges(serverID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.PackageHistoryResponse{Changes: changes, Start: start, End: end})
 }
 
 func (h *Handler) DeleteServer(c *gin.Context) {
@@ -186,13 +405,22 @@ func (h *Handler) DeleteServer(c *gin.Context) {
 		return
 	}
 
+	// 默认将 ID 加入黑名单，防止 Agent 下次上报时重新注册
+	if c.DefaultQuery("block", "true") != "false" {
+		if err := h.db.BlockServer(serverID, "deleted"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.blocked.set(serverID, true)
+	}
+
 	// Delete server and all related data
 	if err := h.db.DeleteServer(serverID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Server deleted successfully"})
+	c.JSON(http.StatusOK, api.SuccessResponse{Success: true, Message: "Server deleted successfully"})
 }
 
 func (h *Handler) AgentReport(c *gin.Context) {
@@ -202,6 +430,23 @@ func (h *Handler) AgentReport(c *gin.Context) {
 		return
 	}
 
+	// 写入事务中会再次检查黑名单
+	blocked, err := h.blocked.contains(report.ServerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Server ID is blocked"})
+		return
+	}
+
+	// 统一转为 UTC，SQLite 以文本比较时间
+	report.Timestamp = report.Timestamp.UTC()
+	if report.Metrics != nil {
+		report.Metrics.Timestamp = report.Metrics.Timestamp.UTC()
+	}
+
 	// Update server status and heartbeat
 	serverName := report.ServerName
 	if serverName == "" {
@@ -228,49 +473,31 @@ func (h *Handler) AgentReport(c *gin.Context) {
 		LastHeartbeat: report.Timestamp,
 	}
 
-	if err := h.db.UpsertServer(server); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if report.Metrics != nil {
+		report.Metrics.ServerID = report.ServerID
 	}
-
-	// Insert metrics
-	if err := h.db.InsertMetrics(&report.Metrics); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if report.Info != nil {
+		report.Info.ServerID = report.ServerID
 	}
-
-	// Update server info
-	if err := h.db.UpsertServerInfo(&report.Info); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Update disks
-	if len(report.Disks) > 0 {
-		if err := h.db.ReplaceDisks(report.ServerID, report.Disks); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	if report.Inventory != nil {
+		report.Inventory.ServerID = report.ServerID
 	}
 
-	// Update processes
-	if len(report.Processes) > 0 {
-		if err := h.db.ReplaceProcesses(report.ServerID, report.Processes); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	}
-
-	// Update network interfaces
-	if len(report.Network) > 0 {
-		if err := h.db.ReplaceNetworkInterfaces(report.ServerID, report.Network); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	// 报告进入写入队列，由后台批量落库
+	err = h.queue.Enqueue(model.ReceivedReport{Server: *server, Report: &report})
+	if err != nil {
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success":            true,
-		"nextReportInterval": 5,
+	c.JSON(http.StatusOK, api.AgentReportResponse{
+		Success:            true,
+		NextReportInterval: 5,
+		Resync:             h.queue.TakeResync(report.ServerID),
 	})
 }
+
+func (h *Handler) GetIngestStats(c *gin.Context) {
+	c.JSON(http.StatusOK, api.IngestStatsResponse{Ingest: h.queue.Stats()})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/pkg/api"
+)
+
+func (h *Handler) setLifecycle(c *gin.Context, lifecycle string) {
+	serverID := c.Param("id")
+
+	// Check if server exists
+	if _, err := h.db.GetServer(serverID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+
+	if err := h.db.SetServerLifecycle(serverID, lifecycle); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	server, err := h.db.GetServer(serverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, api.ServerResponse{Server: server})
+}
+
+// ArchiveServer hides a decommissioned server from the server list while
+// keeping its history. It becomes active again if its agent reports.
+func (h *Handler) ArchiveServer(c *gin.Context) {
+	h.setLifecycle(c, model.LifecycleArchived)
+}
+
+// StartMaintenance puts a server into maintenance, so it shows the
+// maintenance status instead of warning or offline.
+func (h *Handler) StartMaintenance(c *gin.Context) {
+	h.setLifecycle(c, model.LifecycleMaintenance)
+}
+
+// RestoreServer returns an archived or maintenance server to active.
+func (h *Handler) RestoreServer(c *gin.Context) {
+	h.setLifecycle(c, model.LifecycleActive)
+}
+
+func (h *Handler) GetBlockedServers(c *gin.Context) {
+	blocked, err := h.db.GetBlockedServers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, api.BlockedServersResponse{Blocked: blocked})
+}
+
+func (h *Handler) BlockServer(c *gin.Context) {
+	var req api.BlockServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	serverID := strings.TrimSpace(req.ServerID)
+	if serverID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "serverId must not be empty"})
+		return
+	}
+	if err := h.db.BlockServer(serverID, req.Reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.blocked.set(serverID, true)
+	c.JSON(http.StatusOK, api.SuccessResponse{Success: true})
+}
+
+func (h *Handler) UnblockServer(c *gin.Context) {
+	if err := h.db.UnblockServer(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.blocked.set(c.Param("id"), false)
+	c.JSON(http.StatusOK, api.SuccessResponse{Success: true})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/maintenance"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/pkg/api"
+)
+
+// GetMaintenanceWindows lists maintenance windows; pass server to only get
+// the windows that apply to one server.
+func (h *Handler) GetMaintenanceWindows(c *gin.Context) {
+	windows, err := h.db.GetMaintenanceWindows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var server *model.Server
+	if id := c.Query("server"); id != "" {
+		if server, err = h.db.GetServer(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+			return
+		}
+	}
+
+	now := time.Now()
+	items := []api.MaintenanceWindowStatus{}
+	for i := range windows {
+		w := &windows[i]
+		if server != nil && !maintenance.Matches(w, server) {
+			continue
+		}
+		items = append(items, api.MaintenanceWindowStatus{
+			MaintenanceWindow: *w,
+			Active:            maintenance.Active(w, now),
+			Expired:           maintenance.Expired(w, now),
+		})
+	}
+
+	c.JSON(http.StatusOK, api.MaintenanceWindowsResponse{Windows: items})
+}
+
+func (h *Handler) CreateMaintenanceWindow(c *gin.Context) {
+	var w model.MaintenanceWindow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := maintenance.Validate(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.CreateMaintenanceWindow(&w); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.MaintenanceWindowResponse{Window: w})
+}
+
+func (h *Handler) DeleteMaintenanceWindow(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		return
+	}
+
+	if err := h.db.DeleteMaintenanceWindow(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse{Success: true})
+}
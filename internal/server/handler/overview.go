@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/pkg/api"
+)
+
+func (h *Handler) GetOverview(c *gin.Context) {
+	top, err := strconv.Atoi(c.DefaultQuery("top", "5"))
+	if err != nil || top < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid top"})
+		return
+	}
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("disk_threshold", "90"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid disk_threshold"})
+		return
+	}
+
+	overview, err := h.db.Overview(top, threshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	overview.GeneratedAt = time.Now()
+
+	c.JSON(http.StatusOK, api.OverviewResponse{Overview: overview})
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/query"
+)
+
+// maxTimeRange limits how much history a single request may cover.
+const maxTimeRange = 90 * 24 * time.Hour
+
+// parseTimeRange reads the start and end query parameters. end defaults to
+// now; start defaults to end minus the duration parameter, or def when that
+// is absent too.
+func parseTimeRange(c *gin.Context, def time.Duration) (start, end time.Time, err error) {
+	end = time.Now().UTC()
+	if v := c.Query("end"); v != "" {
+		if end, err = query.ParseTime(v); err != nil {
+			return
+		}
+	}
+
+	span := def
+	if v := c.Query("duration"); v != "" {
+		if span, err = time.ParseDuration(v); err != nil || span <= 0 {
+			err = fmt.Errorf("Invalid duration format")
+			return
+		}
+	}
+
+	start = end.Add(-span)
+	if v := c.Query("start"); v != "" {
+		if start, err = query.ParseTime(v); err != nil {
+			return
+		}
+	}
+
+	if !start.Before(end) {
+		err = fmt.Errorf("start must be before end")
+		return
+	}
+	if end.Sub(start) > maxTimeRange {
+		err = fmt.Errorf("time range too large, max %s", maxTimeRange)
+	}
+	return
+}
+
+// parseStep accepts a Go duration ("30s", "5m") or a number of seconds.
+func parseStep(s string) (time.Duration, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(sec) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// maxHistoryPoints is the number of samples above which history endpoints
+// downsample automatically.
+const maxHistoryPoints = 1000
+
+// historyStep reads the optional step parameter of history endpoints; zero
+// means the resolution is chosen automatically. A step that would split
+// [start, end) into more than maxPoints buckets is rejected.
+func historyStep(c *gin.Context, start, end time.Time, maxPoints int) (time.Duration, error) {
+	v := c.Query("step")
+	if v == "" {
+		return 0, nil
+	}
+	step, err := parseStep(v)
+	if err != nil || step < time.Second {
+		return 0, fmt.Errorf("Invalid step, must be at least 1s")
+	}
+	if end.Sub(start)/step > time.Duration(maxPoints) {
+		return 0, fmt.Errorf("too many points, increase step (max %d per series)", maxPoints)
+	}
+	return step, nil
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/query"
+	"github.com/monitor-system/internal/server/selector"
+)
+
+const (
+	maxQueryServers = 50
+	maxQueryPoints  = 10000
+)
+
+// Query returns one aligned series per server for a metric, so several
+// servers can be compared on one chart.
+func (h *Handler) Query(c *gin.Context) {
+	metric := c.DefaultQuery("metric", "cpu")
+	value, ok := query.Metrics[metric]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown metric %q", metric)})
+		return
+	}
+
+	agg := c.DefaultQuery("agg", "avg")
+	if !query.ValidAggregation(agg) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid agg, must be one of: " + strings.Join(query.Aggregations, ", "),
+		})
+		return
+	}
+
+	start, end, err := parseTimeRange(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 默认约 200 个点，最小 5 秒（Agent 默认上报间隔）
+	step := (end.Sub(start) / 200).Round(time.Second)
+	if step < 5*time.Second {
+		step = 5 * time.Second
+	}
+	requested, err := historyStep(c, start, end, maxQueryPoints)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requested > 0 {
+		step = requested
+	}
+
+	servers, err := h.selectServers(c.Query("servers"), c.Query("selector"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(servers) > maxQueryServers {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("too many servers selected (%d, max %d)", len(servers), maxQueryServers),
+		})
+		return
+	}
+
+	ids := make([]string, len(servers))
+	for i, s := range servers {
+		ids[i] = s.ID
+	}
+	samples, err := h.db.GetMetricsRange(ids, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	buckets := query.Buckets(start, end, step)
+	result := query.Result{
+		Metric:      metric,
+		Aggregation: agg,
+		Start:       start,
+		End:         end,
+		Step:        int64(step / time.Second),
+		Timestamps:  buckets,
+		Series:      make([]query.Series, 0, len(servers)),
+	}
+	for _, s := range servers {
+		result.Series = append(result.Series, query.Series{
+			ServerID:   s.ID,
+			ServerName: s.Name,
+			Labels:     s.Labels,
+			Values:     query.Aggregate(samples[s.ID], value, agg, buckets, step),
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// selectServers resolves a comma-separated list of server IDs and/or a label
+// selector. When both are given, the listed servers are filtered by the
+// selector.
+func (h *Handler) selectServers(idList, selectorText string) ([]model.Server, error) {
+	ids := selector.SplitIDs(idList)
+	if len(ids) == 0 && strings.TrimSpace(selectorText) == "" {
+		return nil, fmt.Errorf("servers or selector is required")
+	}
+
+	sel, err := selector.Parse(selectorText)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := h.db.GetServers()
+	if err != nil {
+		return nil, err
+	}
+	return selector.Select(all, ids, sel)
+}
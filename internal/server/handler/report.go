@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/report"
+	"github.com/monitor-system/pkg/api"
+)
+
+func (h *Handler) ListReports(c *gin.Context) {
+	reports, err := h.reports.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.ReportsResponse{Reports: reports})
+}
+
+// CreateReport generates (or regenerates) the daily or weekly report for the
+// period containing ?date=YYYY-MM-DD, by default the last complete one, and
+// mails it when ?email=true.
+func (h *Handler) CreateReport(c *gin.Context) {
+	period := c.DefaultQuery("period", report.PeriodDaily)
+	if period != report.PeriodDaily && period != report.PeriodWeekly {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period, must be daily or weekly"})
+		return
+	}
+	email := c.Query("email") == "true"
+	if email && !h.reports.Emails() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "SMTP is not configured"})
+		return
+	}
+
+	now := time.Now()
+	day := report.LastComplete(period, now)
+	if v := c.Query("date"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, use YYYY-MM-DD"})
+			return
+		}
+		day = t
+	}
+
+	info, err := h.reports.Generate(period, day, now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if email {
+		if err := h.reports.Send(info.Name); err != nil {
+			c.JSON(http.StatusBadGateway, api.ReportResponse{Report: info, Error: err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, api.ReportResponse{Report: info, Emailed: email})
+}
+
+func (h *Handler) DownloadReport(c *gin.Context) {
+	name := c.Param("name")
+	path, err := h.reports.Path(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, name)
+}
+
+// SendReport mails a stored report.
+func (h *Handler) SendReport(c *gin.Context) {
+	if !h.reports.Emails() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "SMTP is not configured"})
+		return
+	}
+
+	name := c.Param("name")
+	if _, err := h.reports.Path(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.reports.Send(name); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.SuccessResponse{Success: true})
+}
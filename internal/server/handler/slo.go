@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/slo"
+	"github.com/monitor-system/pkg/api"
+)
+
+// GetSLOs lists the configured service level objectives.
+func (h *Handler) GetSLOs(c *gin.Context) {
+	objectives := make([]api.SLOObjective, 0, len(h.slo.Objectives()))
+	for _, o := range h.slo.Objectives() {
+		objectives = append(objectives, o.SLOObjective)
+	}
+	c.JSON(http.StatusOK, api.SLOObjectivesResponse{Objectives: objectives})
+}
+
+// GetSLOReport evaluates every objective for one month (?month=YYYY-MM,
+// default the current month) and returns the per-server results as JSON or
+// CSV.
+func (h *Handler) GetSLOReport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, must be json or csv"})
+		return
+	}
+
+	now := time.Now()
+	month, err := slo.ParseMonth(c.Query("month"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var servers []model.Server
+	if c.Query("servers") == "" && strings.TrimSpace(c.Query("selector")) == "" {
+		if servers, err = h.db.GetServers(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else if servers, err = h.selectServers(c.Query("servers"), c.Query("selector")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.slo.Report(month, servers, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="slo-`+report.Month+`.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"month", "objective", "type", "server_id", "server_name", "target",
+		"sli", "good", "total", "met", "budget_remaining", "burn_rate"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	for _, r := range report.Results {
+		row := []string{report.Month, r.Objective, r.Type, r.ServerID, r.ServerName, f(r.Target),
+			f(r.SLI), strconv.FormatInt(r.Good, 10), strconv.FormatInt(r.Total, 10),
+			strconv.FormatBool(r.Met), f(r.BudgetRemaining), f(r.BurnRate)}
+		if r.NoData {
+			// 无数据时指标留空
+			row[6], row[9], row[10], row[11] = "", "", "", ""
+		}
+		w.Write(row)
+	}
+	w.Flush()
+}
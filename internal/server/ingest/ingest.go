@@ -0,0 +1,195 @@
+package ingest
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/pkg/api"
+)
+
+// ErrQueueFull is returned by Enqueue when the writer cannot keep up.
+var ErrQueueFull = errors.New("ingest queue is full")
+
+// ErrClosed is returned by Enqueue after Close.
+var ErrClosed = errors.New("ingest queue is closed")
+
+type Options struct {
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// Stats are cumulative counters since the queue was created.
+type Stats = api.IngestStats
+
+// Queue buffers agent reports and writes them to the store in batches from a
+// single goroutine, so the database sees one transaction per batch instead of
+// several per report.
+type Queue struct {
+	store   database.Store
+	opts    Options
+	reports chan model.ReceivedReport
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	// 写入失败的服务器，下次上报时要求 Agent 重发完整数据
+	resyncMu sync.Mutex
+	resync   map[string]bool
+
+	accepted uint64
+	rejected uint64
+	written  uint64
+	failed   uint64
+	batches  uint64
+}
+
+func New(store database.Store, opts Options) *Queue {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 10000
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 200
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 500 * time.Millisecond
+	}
+
+	q := &Queue{
+		store:   store,
+		opts:    opts,
+		reports: make(chan model.ReceivedReport, opts.QueueSize),
+		done:    make(chan struct{}),
+		resync:  make(map[string]bool),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue hands a report to the writer without blocking.
+func (q *Queue) Enqueue(r model.ReceivedReport) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	select {
+	case q.reports <- r:
+		atomic.AddUint64(&q.accepted, 1)
+		return nil
+	default:
+		atomic.AddUint64(&q.rejected, 1)
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting reports and waits until the queued ones are written.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.reports)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+// TakeResync reports whether a report from serverID failed to be written
+// since the last call, and clears the flag. Reports are acknowledged before
+// they are written, so the agent has to resend its full state to repair the
+// gap.
+func (q *Queue) TakeResync(serverID string) bool {
+	q.resyncMu.Lock()
+	defer q.resyncMu.Unlock()
+
+	if !q.resync[serverID] {
+		return false
+	}
+	delete(q.resync, serverID)
+	return true
+}
+
+func (q *Queue) markFailed(r model.ReceivedReport, err error) {
+	atomic.AddUint64(&q.failed, 1)
+	log.Printf("Failed to save report from %s: %v", r.Server.ID, err)
+
+	q.resyncMu.Lock()
+	q.resync[r.Server.ID] = true
+	q.resyncMu.Unlock()
+}
+
+func (q *Queue) Stats() Stats {
+	return Stats{
+		Queued:   len(q.reports),
+		Capacity: cap(q.reports),
+		Accepted: atomic.LoadUint64(&q.accepted),
+		Rejected: atomic.LoadUint64(&q.rejected),
+		Written:  atomic.LoadUint64(&q.written),
+		Failed:   atomic.LoadUint64(&q.failed),
+		Batches:  atomic.LoadUint64(&q.batches),
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.ReceivedReport, 0, q.opts.BatchSize)
+	for {
+		select {
+		case r, ok := <-q.reports:
+			if !ok {
+				q.flush(batch)
+				return
+			}
+			batch = append(batch, r)
+			if len(batch) >= q.opts.BatchSize {
+				q.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				q.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (q *Queue) flush(batch []model.ReceivedReport) {
+	if len(batch) == 0 {
+		return
+	}
+	atomic.AddUint64(&q.batches, 1)
+
+	err := q.store.SaveReports(batch)
+	if err == nil {
+		atomic.AddUint64(&q.written, uint64(len(batch)))
+		return
+	}
+	if len(batch) == 1 {
+		q.markFailed(batch[0], err)
+		return
+	}
+
+	// 整批失败时逐条重试，避免一条坏数据拖累其他报告
+	log.Printf("Failed to save batch of %d reports, retrying individually: %v", len(batch), err)
+	for i := range batch {
+		if err := q.store.SaveReports(batch[i : i+1]); err != nil {
+			q.markFailed(batch[i], err)
+			continue
+		}
+		atomic.AddUint64(&q.written, 1)
+	}
+}
@@ -0,0 +1,143 @@
+package ingest
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/model"
+)
+
+const benchAgents = 1000
+
+// benchReport builds a report shaped like a default agent's: metrics, one
+// disk, 20 processes and one network interface.
+func benchReport(i int, now time.Time) model.ReceivedReport {
+	id := fmt.Sprintf("bench-%04d", i%benchAgents)
+	report := &model.AgentReport{
+		ServerID:  id,
+		Timestamp: now,
+		Metrics:   &model.Metrics{ServerID: id, Timestamp: now, CPU: 12.5, Memory: 40},
+		Disks: []model.Disk{
+			{Name: "/dev/sda1", MountPoint: "/", FSType: "ext4", TotalSize: 100000, UsedSize: 40000, AvailableSize: 60000, UsagePercent: 40},
+		},
+		Network: []model.NetworkInterface{
+			{Name: "eth0", Type: "ethernet", UploadSpeed: 1.2, DownloadSpeed: 3.4, Status: "up"},
+		},
+	}
+	for p := 0; p < 20; p++ {
+		report.Processes = append(report.Processes, model.Process{
+			PID: int32(1000 + p), Name: fmt.Sprintf("proc-%d", p), CPU: 1, Memory: 0.5, User: "root", Status: "running",
+		})
+	}
+	return model.ReceivedReport{
+		Server: model.Server{ID: id, Name: id, IP: "10.0.0.1", OS: "linux", Status: "online", LastHeartbeat: now},
+		Report: report,
+	}
+}
+
+func openBenchDB(b *testing.B, journal string) *database.DB {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), "bench.db")
+	db, err := database.New(path + "?_journal_mode=" + journal + "&_synchronous=NORMAL&_busy_timeout=5000&_txlock=immediate")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	if err := db.Initialize(); err != nil {
+		b.Fatal(err)
+	}
+
+	// 先注册全部 Agent，测量的是稳定运行时的上报写入
+	now := time.Now()
+	var batch []model.ReceivedReport
+	for i := 0; i < benchAgents; i++ {
+		batch = append(batch, benchReport(i, now))
+	}
+	if err := db.SaveReports(batch); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+// BenchmarkQueue measures end-to-end ingest throughput from 1000 agents into
+// SQLite, per report and in batches, with and without WAL:
+//
+//	go test -run '^$' -bench Queue ./internal/server/ingest
+func BenchmarkQueue(b *testing.B) {
+	cases := []struct {
+		name    string
+		journal string
+		batch   int
+	}{
+		{"delete/single", "DELETE", 1},
+		{"wal/single", "WAL", 1},
+		{"wal/batch", "WAL", 200},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			db := openBenchDB(b, tc.journal)
+			now := time.Now()
+			reports := make([]model.ReceivedReport, b.N)
+			for i := range reports {
+				reports[i] = benchReport(i, now.Add(time.Duration(i)*time.Millisecond))
+			}
+
+			b.ResetTimer()
+			q := New(db, Options{QueueSize: b.N, BatchSize: tc.batch, FlushInterval: 50 * time.Millisecond})
+			for _, r := range reports {
+				if err := q.Enqueue(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+			q.Close()
+			b.StopTimer()
+
+			if s := q.Stats(); s.Failed > 0 {
+				b.Fatalf("%d reports failed", s.Failed)
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "reports/s")
+		})
+	}
+}
+
+// failingStore rejects reports from one server.
+type failingStore struct {
+	database.Store
+	bad string
+}
+
+func (s *failingStore) SaveReports(reports []model.ReceivedReport) error {
+	for _, r := range reports {
+		if r.Server.ID == s.bad {
+			return fmt.Errorf("write failed")
+		}
+	}
+	return nil
+}
+
+func TestQueueResyncAfterFailedWrite(t *testing.T) {
+	q := New(&failingStore{bad: "b"}, Options{BatchSize: 10})
+	for _, id := range []string{"a", "b", "c"} {
+		if err := q.Enqueue(model.ReceivedReport{Server: model.Server{ID: id}, Report: &model.AgentReport{ServerID: id}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Close()
+
+	s := q.Stats()
+	if s.Written != 2 || s.Failed != 1 {
+		t.Fatalf("written=%d failed=%d, want 2 and 1", s.Written, s.Failed)
+	}
+	if q.TakeResync("a") {
+		t.Error("a was written but asked to resync")
+	}
+	if !q.TakeResync("b") {
+		t.Error("b failed but was not asked to resync")
+	}
+	if q.TakeResync("b") {
+		t.Error("resync for b was not cleared")
+	}
+}
@@ -0,0 +1,154 @@
+// Package maintenance decides which servers are inside a maintenance window.
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/selector"
+)
+
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+func period(recurrence string) time.Duration {
+	switch recurrence {
+	case Daily:
+		return 24 * time.Hour
+	case Weekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// Validate checks a window before it is stored.
+func Validate(w *model.MaintenanceWindow) error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(w.Servers) == 0 && strings.TrimSpace(w.Selector) == "" {
+		return fmt.Errorf("servers or selector is required")
+	}
+	if _, err := selector.Parse(w.Selector); err != nil {
+		return err
+	}
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	switch w.Recurrence {
+	case "":
+	case Daily, Weekly:
+		if w.End.Sub(w.Start) >= period(w.Recurrence) {
+			return fmt.Errorf("a %s window must be shorter than %s", w.Recurrence, period(w.Recurrence))
+		}
+	default:
+		return fmt.Errorf("invalid recurrence, must be daily or weekly")
+	}
+	if w.Until != nil && !w.Until.After(w.Start) {
+		return fmt.Errorf("until must be after start")
+	}
+	return nil
+}
+
+// Active reports whether t falls inside an occurrence of w. Recurring
+// windows repeat at a fixed 24h or 7×24h period from Start.
+func Active(w *model.MaintenanceWindow, t time.Time) bool {
+	if t.Before(w.Start) {
+		return false
+	}
+	if w.Recurrence == "" {
+		return t.Before(w.End)
+	}
+	if w.Until != nil && !t.Before(*w.Until) {
+		return false
+	}
+	offset := t.Sub(w.Start) % period(w.Recurrence)
+	return offset < w.End.Sub(w.Start)
+}
+
+// Expired reports whether w has no occurrences left after t.
+func Expired(w *model.MaintenanceWindow, t time.Time) bool {
+	if w.Recurrence == "" {
+		return !t.Before(w.End)
+	}
+	return w.Until != nil && !t.Before(*w.Until)
+}
+
+// Matches reports whether w applies to server s.
+func Matches(w *model.MaintenanceWindow, s *model.Server) bool {
+	if len(w.Servers) > 0 {
+		found := false
+		for _, id := range w.Servers {
+			if id == s.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	sel, err := selector.Parse(w.Selector)
+	if err != nil {
+		return false
+	}
+	return sel.Matches(s.Labels)
+}
+
+// Applicable returns the windows that apply to server s. Callers checking
+// many timestamps for one server filter once and then test Active.
+func Applicable(windows []model.MaintenanceWindow, s *model.Server) []model.MaintenanceWindow {
+	var out []model.MaintenanceWindow
+	for i := range windows {
+		if Matches(&windows[i], s) {
+			out = append(out, windows[i])
+		}
+	}
+	return out
+}
+
+// inWindow reports whether server s is inside one of windows at t.
+func inWindow(windows []model.MaintenanceWindow, s *model.Server, t time.Time) bool {
+	for i := range windows {
+		if Active(&windows[i], t) && Matches(&windows[i], s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveServers returns the IDs of servers inside a maintenance window at t.
+func ActiveServers(db database.Store, t time.Time) ([]string, error) {
+	windows, err := db.GetMaintenanceWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	var active []model.MaintenanceWindow
+	for _, w := range windows {
+		if Active(&w, t) {
+			active = append(active, w)
+		}
+	}
+	if len(active) == 0 {
+		return nil, nil
+	}
+
+	servers, err := db.GetServers()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for i := range servers {
+		if inWindow(active, &servers[i], t) {
+			ids = append(ids, servers[i].ID)
+		}
+	}
+	return ids, nil
+}
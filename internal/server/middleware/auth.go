@@ -30,11 +30,31 @@ func AgentAuthMiddleware(agentKey string) gin.HandlerFunc {
 	}
 }
 
+// AdminAuthMiddleware guards the admin API with its own key, separate from
+// the API key the dashboard keeps in the browser. An empty key disables the
+// admin API.
+func AdminAuthMiddleware(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminKey == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin API is disabled, set auth.admin_key"})
+			c.Abort()
+			return
+		}
+		key := c.GetHeader("X-Admin-Key")
+		if key != adminKey {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-API-Key, X-Agent-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-API-Key, X-Agent-Key, X-Admin-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
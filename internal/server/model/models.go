@@ -1,76 +1,79 @@
 package model
 
-import "time"
+import "github.com/monitor-system/pkg/api"
 
-type Server struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	IP            string    `json:"ip"`
-	Status        string    `json:"status"`
-	OS            string    `json:"os"`
-	Location      string    `json:"location"`
-	LastHeartbeat time.Time `json:"lastHeartbeat"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
-}
+// Types that appear in API requests and responses are defined in pkg/api,
+// so the server and its clients share them.
+type (
+	Server            = api.Server
+	BlockedServer     = api.BlockedServer
+	Metrics           = api.Metrics
+	ServerInfo        = api.ServerInfo
+	Inventory         = api.Inventory
+	Package           = api.Package
+	PackageReport     = api.PackageReport
+	ServerPackage     = api.ServerPackage
+	PackageChange     = api.PackageChange
+	Sensor            = api.Sensor
+	SensorPoint       = api.SensorPoint
+	SensorSeries      = api.SensorSeries
+	Disk              = api.Disk
+	Process           = api.Process
+	NetworkInterface  = api.NetworkInterface
+	AgentStatus       = api.AgentStatus
+	CollectorStatus   = api.CollectorStatus
+	AgentReport       = api.AgentReport
+	Overview          = api.Overview
+	Summary           = api.Summary
+	ServerValue       = api.ServerValue
+	DiskUsage         = api.DiskUsage
+	StorageStats      = api.StorageStats
+	TableStats        = api.TableStats
+	MaintenanceWindow = api.MaintenanceWindow
+	Anomaly           = api.Anomaly
+)
 
-type Metrics struct {
-	ServerID   string    `json:"serverId"`
-	Timestamp  time.Time `json:"timestamp"`
-	CPU        float64   `json:"cpu"`
-	Memory     float64   `json:"memory"`
-	DiskRead   float64   `json:"diskRead"`
-	DiskWrite  float64   `json:"diskWrite"`
-	NetworkIn  float64   `json:"networkIn"`
-	NetworkOut float64   `json:"networkOut"`
-}
+const (
+	LifecycleActive      = api.LifecycleActive
+	LifecycleMaintenance = api.LifecycleMaintenance
+	LifecycleArchived    = api.LifecycleArchived
 
-type ServerInfo struct {
-	ServerID    string `json:"serverId"`
-	CPUCores    int    `json:"cpuCores"`
-	TotalMemory int64  `json:"totalMemory"`
-	UsedMemory  int64  `json:"usedMemory"`
-	Uptime      int64  `json:"uptime"`
-}
+	SensorTemperature = api.SensorTemperature
+	SensorFan         = api.SensorFan
+)
 
-type Disk struct {
-	Name          string  `json:"name"`
-	MountPoint    string  `json:"mountPoint"`
-	FSType        string  `json:"fsType"`
-	TotalSize     uint64  `json:"totalSize"`
-	UsedSize      uint64  `json:"usedSize"`
-	AvailableSize uint64  `json:"availableSize"`
-	UsagePercent  float64 `json:"usagePercent"`
+// ServerQuery selects a page of the server list.
+type ServerQuery struct {
+	Search    string // 匹配名称、IP、位置或 ID
+	Status    string
+	Lifecycle string // 为空时不含已归档服务器，"all" 表示全部
+	Sort      string // ServerSortFields 之一，默认 name
+	Desc      bool
+	Limit     int // 0 表示不分页
+	Offset    int
 }
 
-type Process struct {
-	PID    int32   `json:"pid"`
-	Name   string  `json:"name"`
-	CPU    float64 `json:"cpu"`
-	Memory float64 `json:"memory"`
-	User   string  `json:"user"`
-	Status string  `json:"status"`
+// ServerSortFields lists the fields the server list can be sorted by.
+var ServerSortFields = api.ServerSortFields
+
+// ServerListItem is a server with its latest metrics, if any.
+type ServerListItem struct {
+	Server
+	Metrics *Metrics
 }
 
-type NetworkInterface struct {
-	Name          string  `json:"name"`
-	Type          string  `json:"type"`
-	UploadSpeed   float64 `json:"uploadSpeed"`
-	DownloadSpeed float64 `json:"downloadSpeed"`
-	TotalUpload   uint64  `json:"totalUpload"`
-	TotalDownload uint64  `json:"totalDownload"`
-	Status        string  `json:"status"`
+// InventoryFilter selects inventory records; empty fields match everything.
+type InventoryFilter struct {
+	Platform        string
+	PlatformFamily  string
+	PlatformVersion string
+	Kernel          string // 内核版本前缀
+	Arch            string
 }
 
-type AgentReport struct {
-	ServerID   string             `json:"serverId"`
-	ServerName string             `json:"serverName,omitempty"` // Agent 配置中的服务器名称
-	OS         string             `json:"os,omitempty"`         // 操作系统信息
-	Location   string             `json:"location,omitempty"`   // 服务器位置
-	Timestamp  time.Time          `json:"timestamp"`
-	Metrics    Metrics            `json:"metrics"`
-	Info       ServerInfo         `json:"info"`
-	Disks      []Disk             `json:"disks"`
-	Processes  []Process          `json:"processes"`
-	Network    []NetworkInterface `json:"network"`
+// ReceivedReport is an accepted agent report together with the server record
+// built from the request, waiting to be written.
+type ReceivedReport struct {
+	Server Server
+	Report *AgentReport
 }
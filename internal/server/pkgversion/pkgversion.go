@@ -0,0 +1,165 @@
+// Package pkgversion compares package version strings using the dpkg
+// ordering rules, which also give sensible results for rpm versions.
+package pkgversion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Compare returns -1, 0 or 1 when a is older than, equal to or newer than b.
+func Compare(a, b string) int {
+	epochA, upstreamA, revisionA := split(a)
+	epochB, upstreamB, revisionB := split(b)
+
+	if epochA != epochB {
+		if epochA < epochB {
+			return -1
+		}
+		return 1
+	}
+	if c := compareFragment(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareFragment(revisionA, revisionB)
+}
+
+// split breaks "epoch:upstream-revision" into its parts.
+func split(v string) (int, string, string) {
+	v = strings.TrimSpace(v)
+
+	epoch := 0
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		if n, err := strconv.Atoi(v[:i]); err == nil {
+			epoch = n
+			v = v[i+1:]
+		}
+	}
+
+	revision := ""
+	if i := strings.LastIndexByte(v, '-'); i >= 0 {
+		revision = v[i+1:]
+		v = v[:i]
+	}
+
+	return epoch, v, revision
+}
+
+// order gives the dpkg sort weight of a non-digit character: '~' sorts before
+// everything, letters before other symbols.
+func order(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case c >= '0' && c <= '9':
+		return 0
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func compareFragment(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		// Non-digit prefix
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			var ca, cb int
+			if i < len(a) && !isDigit(a[i]) {
+				ca = order(a[i])
+			}
+			if j < len(b) && !isDigit(b[j]) {
+				cb = order(b[j])
+			}
+			if ca != cb {
+				if ca < cb {
+					return -1
+				}
+				return 1
+			}
+			i++
+			j++
+		}
+
+		// Numeric part, compared without leading zeros
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		startA, startB := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		numA, numB := a[startA:i], b[startB:j]
+		if len(numA) != len(numB) {
+			if len(numA) < len(numB) {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(numA, numB); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// Constraint is a version comparison such as "<3.0.2" or ">=1.1".
+type Constraint struct {
+	Op      string
+	Version string
+}
+
+// ParseConstraint parses an operator followed by a version. A bare version
+// means equality.
+func ParseConstraint(expr string) (Constraint, error) {
+	expr = strings.TrimSpace(expr)
+	for _, op := range []string{"<=", ">=", "!=", "==", "<", ">", "="} {
+		if strings.HasPrefix(expr, op) {
+			version := strings.TrimSpace(expr[len(op):])
+			if version == "" {
+				return Constraint{}, fmt.Errorf("missing version in %q", expr)
+			}
+			if op == "==" {
+				op = "="
+			}
+			return Constraint{Op: op, Version: version}, nil
+		}
+	}
+
+	if expr == "" {
+		return Constraint{}, fmt.Errorf("empty version constraint")
+	}
+	return Constraint{Op: "=", Version: expr}, nil
+}
+
+// Matches reports whether version satisfies the constraint.
+func (c Constraint) Matches(version string) bool {
+	cmp := Compare(version, c.Version)
+	switch c.Op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "!=":
+		return cmp != 0
+	default:
+		return cmp == 0
+	}
+}
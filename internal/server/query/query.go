@@ -0,0 +1,226 @@
+// Package query turns raw metric samples into aligned, aggregated series for
+// comparing several servers on one chart.
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/pkg/api"
+)
+
+// Metrics maps the queryable metric names (the columns of the metrics table)
+// to their values.
+var Metrics = map[string]func(m *model.Metrics) float64{
+	"cpu":         func(m *model.Metrics) float64 { return m.CPU },
+	"memory":      func(m *model.Metrics) float64 { return m.Memory },
+	"disk_read":   func(m *model.Metrics) float64 { return m.DiskRead },
+	"disk_write":  func(m *model.Metrics) float64 { return m.DiskWrite },
+	"network_in":  func(m *model.Metrics) float64 { return m.NetworkIn },
+	"network_out": func(m *model.Metrics) float64 { return m.NetworkOut },
+}
+
+// Aggregations lists the supported aggregation functions.
+var Aggregations = []string{"avg", "max", "min", "p95", "rate"}
+
+// ValidAggregation reports whether name is one of Aggregations.
+func ValidAggregation(name string) bool {
+	for _, a := range Aggregations {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+type (
+	Series = api.QuerySeries
+	Result = api.QueryResult
+)
+
+// ParseTime accepts RFC3339 timestamps or Unix seconds.
+func ParseTime(s string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use RFC3339 or Unix seconds", s)
+	}
+	return t.UTC(), nil
+}
+
+// Buckets returns the start of every step-wide bucket covering [start, end).
+// Buckets are aligned to multiples of step so repeated queries line up.
+func Buckets(start, end time.Time, step time.Duration) []time.Time {
+	var buckets []time.Time
+	for t := start.Truncate(step); t.Before(end); t = t.Add(step) {
+		buckets = append(buckets, t)
+	}
+	return buckets
+}
+
+// Aggregate groups samples into the given buckets and applies agg to each.
+// Samples must be in ascending time order.
+func Aggregate(samples []model.Metrics, value func(m *model.Metrics) float64,
+	agg string, buckets []time.Time, step time.Duration) []*float64 {
+
+	values := make([]*float64, len(buckets))
+	if len(buckets) == 0 {
+		return values
+	}
+
+	first := buckets[0]
+	groups := make([][]*model.Metrics, len(buckets))
+	for i := range samples {
+		idx := int(samples[i].Timestamp.Sub(first) / step)
+		if samples[i].Timestamp.Before(first) || idx >= len(buckets) {
+			continue
+		}
+		groups[idx] = append(groups[idx], &samples[i])
+	}
+
+	for i, group := range groups {
+		if v, ok := apply(group, value, agg); ok {
+			values[i] = &v
+		}
+	}
+	return values
+}
+
+func apply(group []*model.Metrics, value func(m *model.Metrics) float64, agg string) (float64, bool) {
+	if len(group) == 0 {
+		return 0, false
+	}
+
+	switch agg {
+	case "rate":
+		// 桶内首尾两点的每秒变化量
+		if len(group) < 2 {
+			return 0, false
+		}
+		first, last := group[0], group[len(group)-1]
+		seconds := last.Timestamp.Sub(first.Timestamp).Seconds()
+		if seconds <= 0 {
+			return 0, false
+		}
+		return (value(last) - value(first)) / seconds, true
+	case "p95":
+		vals := make([]float64, len(group))
+		for i, m := range group {
+			vals[i] = value(m)
+		}
+		sort.Float64s(vals)
+		rank := int(math.Ceil(0.95 * float64(len(vals))))
+		return vals[rank-1], true
+	case "max", "min":
+		result := value(group[0])
+		for _, m := range group[1:] {
+			v := value(m)
+			if (agg == "max" && v > result) || (agg == "min" && v < result) {
+				result = v
+			}
+		}
+		return result, true
+	default: // avg
+		sum := 0.0
+		for _, m := range group {
+			sum += value(m)
+		}
+		return sum / float64(len(group)), true
+	}
+}
+
+// DownsampleMetrics averages every field of the samples within each bucket.
+// Empty buckets are skipped; each result is stamped with its bucket start.
+func DownsampleMetrics(samples []model.Metrics, buckets []time.Time, step time.Duration) []model.Metrics {
+	if len(buckets) == 0 {
+		return []model.Metrics{}
+	}
+
+	first := buckets[0]
+	sums := make([]model.Metrics, len(buckets))
+	counts := make([]int, len(buckets))
+	for _, m := range samples {
+		idx := int(m.Timestamp.Sub(first) / step)
+		if m.Timestamp.Before(first) || idx >= len(buckets) {
+			continue
+		}
+		s := &sums[idx]
+		s.ServerID = m.ServerID
+		s.CPU += m.CPU
+		s.Memory += m.Memory
+		s.DiskRead += m.DiskRead
+		s.DiskWrite += m.DiskWrite
+		s.NetworkIn += m.NetworkIn
+		s.NetworkOut += m.NetworkOut
+		counts[idx]++
+	}
+
+	result := []model.Metrics{}
+	for i, s := range sums {
+		n := float64(counts[i])
+		if n == 0 {
+			continue
+		}
+		result = append(result, model.Metrics{
+			ServerID:   s.ServerID,
+			Timestamp:  buckets[i],
+			CPU:        s.CPU / n,
+			Memory:     s.Memory / n,
+			DiskRead:   s.DiskRead / n,
+			DiskWrite:  s.DiskWrite / n,
+			NetworkIn:  s.NetworkIn / n,
+			NetworkOut: s.NetworkOut / n,
+		})
+	}
+	return result
+}
+
+// DownsamplePoints averages sensor points within each bucket.
+func DownsamplePoints(points []model.SensorPoint, buckets []time.Time, step time.Duration) []model.SensorPoint {
+	if len(buckets) == 0 {
+		return []model.SensorPoint{}
+	}
+
+	first := buckets[0]
+	sums := make([]float64, len(buckets))
+	counts := make([]int, len(buckets))
+	for _, p := range points {
+		idx := int(p.Timestamp.Sub(first) / step)
+		if p.Timestamp.Before(first) || idx >= len(buckets) {
+			continue
+		}
+		sums[idx] += p.Value
+		counts[idx]++
+	}
+
+	result := []model.SensorPoint{}
+	for i, sum := range sums {
+		if counts[i] > 0 {
+			result = append(result, model.SensorPoint{Timestamp: buckets[i], Value: sum / float64(counts[i])})
+		}
+	}
+	return result
+}
+
+// Resolution picks the bucket width for a history request: the requested
+// step if any, otherwise zero (raw samples) when count fits in maxPoints, or
+// the smallest whole-second step that does.
+func Resolution(start, end time.Time, requested time.Duration, count, maxPoints int) time.Duration {
+	if requested > 0 {
+		return requested
+	}
+	if count <= maxPoints {
+		return 0
+	}
+	step := end.Sub(start) / time.Duration(maxPoints)
+	if step%time.Second != 0 {
+		step = step.Truncate(time.Second) + time.Second
+	}
+	return step
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Charts are rendered as inline SVG so a report is a single self-contained
+// file that displays in browsers and mail clients without scripts. Values
+// are percentages; the y axis is fixed to 0-100.
+
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []*float64 // 与时间轴对齐，nil 表示无数据
+}
+
+const (
+	chartWidth   = 720
+	chartHeight  = 220
+	chartPadLeft = 36
+	chartPadBot  = 22
+	chartPadTop  = 10
+	chartPadR    = 10
+)
+
+// lineChart draws each series as a polyline over the bucket timestamps,
+// breaking the line where there is no data.
+func lineChart(buckets []time.Time, series []chartSeries) template.HTML {
+	plotW := float64(chartWidth - chartPadLeft - chartPadR)
+	plotH := float64(chartHeight - chartPadTop - chartPadBot)
+	x := func(i int) float64 {
+		if len(buckets) <= 1 {
+			return chartPadLeft
+		}
+		return chartPadLeft + plotW*float64(i)/float64(len(buckets)-1)
+	}
+	y := func(v float64) float64 {
+		return chartPadTop + plotH*(1-clamp(v)/100)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="10">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+
+	// 网格与纵轴刻度
+	for _, v := range []float64{0, 25, 50, 75, 100} {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e5e7eb"/>`,
+			chartPadLeft, y(v), chartWidth-chartPadR, y(v))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" fill="#6b7280">%.0f%%</text>`,
+			chartPadLeft-4, y(v)+3, v)
+	}
+
+	// 横轴标签，最多约 8 个
+	if n := len(buckets); n > 0 {
+		every := max(1, (n+7)/8)
+		layout := "15:04"
+		if buckets[n-1].Sub(buckets[0]) > 24*time.Hour {
+			layout = "01-02"
+		}
+		for i := 0; i < n; i += every {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#6b7280">%s</text>`,
+				x(i), chartHeight-6, buckets[i].Format(layout))
+		}
+	}
+
+	for _, s := range series {
+		for _, points := range segments(s.Values, x, y) {
+			fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, s.Color, points)
+		}
+	}
+
+	// 图例
+	for i, s := range series {
+		lx := chartPadLeft + 8 + i*80
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, lx, chartPadTop+2, s.Color)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#111827">%s</text>`, lx+14, chartPadTop+11, html.EscapeString(s.Name))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// sparkline draws a small trend line without axes.
+func sparkline(values []*float64) template.HTML {
+	const w, h = 160, 30
+	x := func(i int) float64 {
+		if len(values) <= 1 {
+			return 0
+		}
+		return w * float64(i) / float64(len(values)-1)
+	}
+	y := func(v float64) float64 { return 1 + (h-2)*(1-clamp(v)/100) }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#f9fafb"/>`, w, h)
+	for _, points := range segments(values, x, y) {
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="#2563eb" stroke-width="1" points="%s"/>`, points)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// segments turns values into polyline point lists, one per run of
+// consecutive non-nil values. A lone point is drawn as a short dash.
+func segments(values []*float64, x func(int) float64, y func(float64) float64) []string {
+	var out []string
+	var run [][2]float64
+	flush := func() {
+		if len(run) == 1 {
+			p := run[0]
+			run = [][2]float64{{p[0] - 1, p[1]}, {p[0] + 1, p[1]}}
+		}
+		if len(run) > 0 {
+			points := make([]string, len(run))
+			for i, p := range run {
+				points[i] = fmt.Sprintf("%.1f,%.1f", p[0], p[1])
+			}
+			out = append(out, strings.Join(points, " "))
+		}
+		run = nil
+	}
+	for i, v := range values {
+		if v == nil {
+			flush()
+			continue
+		}
+		run = append(run, [2]float64{x(i), y(*v)})
+	}
+	flush()
+	return out
+}
+
+func clamp(v float64) float64 {
+	return min(max(v, 0), 100)
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"html/template"
+	"sort"
+	"time"
+
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/query"
+	"github.com/monitor-system/internal/server/slo"
+)
+
+// maxAnomalies caps the anomaly table so a noisy period stays readable.
+const maxAnomalies = 100
+
+type serverAvailability struct {
+	Server       model.Server
+	Availability float64
+	NoData       bool
+	BelowTarget  bool
+}
+
+type consumer struct {
+	Server    model.Server
+	AvgCPU    float64
+	MaxCPU    float64
+	AvgMemory float64
+	MaxMemory float64
+	Chart     template.HTML
+}
+
+type fullDisk struct {
+	Server model.Server
+	Disk   model.Disk
+}
+
+type anomalyRow struct {
+	Server  model.Server
+	Anomaly model.Anomaly
+}
+
+type pageData struct {
+	Title       string
+	Period      string
+	Start       time.Time
+	End         time.Time
+	GeneratedAt time.Time
+
+	ServerCount     int
+	Online          int
+	AvgAvailability float64 // 有数据服务器的平均可用性
+	Target          float64
+	DiskThreshold   float64
+
+	FleetChart   template.HTML
+	Availability []serverAvailability
+	Consumers    []consumer
+	Disks        []fullDisk
+	Anomalies    []anomalyRow
+	AnomalyCount int
+}
+
+// step is the chart resolution for a period.
+func step(period string) time.Duration {
+	if period == PeriodWeekly {
+		return time.Hour
+	}
+	return 15 * time.Minute
+}
+
+func (m *Manager) collect(period string, start, end, now time.Time) (*pageData, error) {
+	servers, err := activeServers(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &pageData{
+		Title:         subject(period, start),
+		Period:        period,
+		Start:         start,
+		End:           end,
+		GeneratedAt:   now,
+		ServerCount:   len(servers),
+		Target:        m.opts.AvailabilityTarget,
+		DiskThreshold: m.opts.DiskThreshold,
+	}
+	for _, s := range servers {
+		if s.Status == "online" {
+			data.Online++
+		}
+	}
+
+	// 可用性
+	results, err := slo.Availability(m.db, servers, start, end, m.opts.Slot, m.opts.AvailabilityTarget)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Server, len(servers))
+	for _, s := range servers {
+		byID[s.ID] = s
+	}
+	sum, counted := 0.0, 0
+	for _, r := range results {
+		a := serverAvailability{Server: byID[r.ServerID], Availability: r.SLI, NoData: r.NoData}
+		if !r.NoData {
+			a.BelowTarget = !r.Met
+			sum += r.SLI
+			counted++
+		}
+		data.Availability = append(data.Availability, a)
+	}
+	if counted > 0 {
+		data.AvgAvailability = sum / float64(counted)
+	}
+	sort.SliceStable(data.Availability, func(i, j int) bool {
+		a, b := data.Availability[i], data.Availability[j]
+		if a.NoData != b.NoData {
+			return b.NoData
+		}
+		return a.Availability < b.Availability
+	})
+
+	// 资源占用与整体趋势
+	bucketStep := step(period)
+	buckets := query.Buckets(start, end, bucketStep)
+	cpuSum := make([]float64, len(buckets))
+	memSum := make([]float64, len(buckets))
+	counts := make([]int, len(buckets))
+	var consumers []consumer
+	for _, s := range servers {
+		history, err := m.db.GetMetricsHistory(s.ID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if len(history) == 0 {
+			continue
+		}
+
+		c := consumer{Server: s}
+		for _, h := range history {
+			c.AvgCPU += h.CPU
+			c.AvgMemory += h.Memory
+			c.MaxCPU = max(c.MaxCPU, h.CPU)
+			c.MaxMemory = max(c.MaxMemory, h.Memory)
+		}
+		c.AvgCPU /= float64(len(history))
+		c.AvgMemory /= float64(len(history))
+
+		cpu := query.Aggregate(history, query.Metrics["cpu"], "avg", buckets, bucketStep)
+		mem := query.Aggregate(history, query.Metrics["memory"], "avg", buckets, bucketStep)
+		for i := range buckets {
+			if cpu[i] != nil && mem[i] != nil {
+				cpuSum[i] += *cpu[i]
+				memSum[i] += *mem[i]
+				counts[i]++
+			}
+		}
+		c.Chart = sparkline(cpu)
+		consumers = append(consumers, c)
+	}
+	sort.Slice(consumers, func(i, j int) bool { return consumers[i].AvgCPU > consumers[j].AvgCPU })
+	data.Consumers = consumers[:min(m.opts.TopN, len(consumers))]
+
+	fleetCPU := make([]*float64, len(buckets))
+	fleetMem := make([]*float64, len(buckets))
+	for i, n := range counts {
+		if n > 0 {
+			cpu, mem := cpuSum[i]/float64(n), memSum[i]/float64(n)
+			fleetCPU[i], fleetMem[i] = &cpu, &mem
+		}
+	}
+	data.FleetChart = lineChart(buckets, []chartSeries{
+		{Name: "CPU", Color: "#2563eb", Values: fleetCPU},
+		{Name: "内存", Color: "#16a34a", Values: fleetMem},
+	})
+
+	// 磁盘（最新快照）
+	disks, err := m.db.GetAllDisks()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range servers {
+		for _, d := range disks[s.ID] {
+			if d.UsagePercent >= m.opts.DiskThreshold {
+				data.Disks = append(data.Disks, fullDisk{Server: s, Disk: d})
+			}
+		}
+	}
+	sort.Slice(data.Disks, func(i, j int) bool {
+		return data.Disks[i].Disk.UsagePercent > data.Disks[j].Disk.UsagePercent
+	})
+
+	// 异常事件
+	for _, s := range servers {
+		anomalies, err := m.db.GetAnomalies(s.ID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range anomalies {
+			data.Anomalies = append(data.Anomalies, anomalyRow{Server: s, Anomaly: a})
+		}
+	}
+	data.AnomalyCount = len(data.Anomalies)
+	sort.Slice(data.Anomalies, func(i, j int) bool {
+		return data.Anomalies[i].Anomaly.StartedAt.After(data.Anomalies[j].Anomaly.StartedAt)
+	})
+	data.Anomalies = data.Anomalies[:min(maxAnomalies, len(data.Anomalies))]
+
+	return data, nil
+}
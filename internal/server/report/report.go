@@ -0,0 +1,308 @@
+// Package report renders daily and weekly HTML summaries of the fleet,
+// stores them on disk and optionally mails them.
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/pkg/api"
+)
+
+const (
+	PeriodDaily  = api.PeriodDaily
+	PeriodWeekly = api.PeriodWeekly
+
+	filePrefix = "report-"
+	fileSuffix = ".html"
+	sentSuffix = ".sent" // 邮件发送成功后写入的标记文件
+	dateFormat = "20060102"
+)
+
+// Options configures a Manager.
+type Options struct {
+	Dir                string
+	Keep               int     // 保留的报告数量，<= 0 表示不清理
+	TopN               int     // 资源占用排行的服务器数量
+	DiskThreshold      float64 // 磁盘使用率超过该值时列出
+	AvailabilityTarget float64 // 可用性低于该值时标红
+	Slot               time.Duration
+	SMTP               *SMTP // nil 表示不发送邮件
+}
+
+// Info describes one stored report.
+type Info = api.ReportInfo
+
+// Manager generates reports into a directory and keeps the newest Keep.
+type Manager struct {
+	db   database.Store
+	opts Options
+	mu   sync.Mutex
+}
+
+func New(db database.Store, opts Options) *Manager {
+	return &Manager{db: db, opts: opts}
+}
+
+// Emails reports whether generated reports can be mailed.
+func (m *Manager) Emails() bool {
+	return m.opts.SMTP != nil
+}
+
+// Bounds returns the period containing day: the calendar day, or the week
+// starting on Monday, in local time.
+func Bounds(period string, day time.Time) (start, end time.Time, err error) {
+	day = day.In(time.Local)
+	start = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	switch period {
+	case PeriodDaily:
+		return start, start.AddDate(0, 0, 1), nil
+	case PeriodWeekly:
+		offset := (int(start.Weekday()) + 6) % 7 // 周一为 0
+		start = start.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q, must be daily or weekly", period)
+	}
+}
+
+// LastComplete returns a day within the most recent period that has fully
+// ended at now.
+func LastComplete(period string, now time.Time) time.Time {
+	start, _, _ := Bounds(period, now)
+	return start.Add(-time.Hour)
+}
+
+// Generate renders the report for the period containing day and writes it,
+// replacing an existing report for the same period. A period that has not
+// ended yet is reported up to now.
+func (m *Manager) Generate(period string, day, now time.Time) (Info, error) {
+	start, end, err := Bounds(period, day)
+	if err != nil {
+		return Info{}, err
+	}
+	if !start.Before(now) {
+		return Info{}, fmt.Errorf("period starting %s has not begun", start.Format("2006-01-02"))
+	}
+	if end.After(now) {
+		end = now
+	}
+
+	data, err := m.collect(period, start, end, now)
+	if err != nil {
+		return Info{}, err
+	}
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		return Info{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.opts.Dir, 0755); err != nil {
+		return Info{}, err
+	}
+	name := fileName(period, start)
+	path := filepath.Join(m.opts.Dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		os.Remove(tmp)
+		return Info{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Info{}, err
+	}
+
+	info := Info{Name: name, Period: period, Start: start, End: end, Size: int64(buf.Len()), CreatedAt: now}
+	if err := m.rotate(); err != nil {
+		return info, fmt.Errorf("report created but rotation failed: %w", err)
+	}
+	return info, nil
+}
+
+// Send mails a stored report as an HTML email and records the delivery.
+func (m *Manager) Send(name string) error {
+	if m.opts.SMTP == nil {
+		return fmt.Errorf("smtp is not configured")
+	}
+	path, err := m.Path(name)
+	if err != nil {
+		return err
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	period, start, _ := parseName(name)
+	if err := m.opts.SMTP.Send(subject(period, start), body); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+sentSuffix, nil, 0644); err != nil {
+		return fmt.Errorf("report sent but delivery not recorded: %w", err)
+	}
+	return nil
+}
+
+// Sent reports whether the named report has been mailed.
+func (m *Manager) Sent(name string) bool {
+	_, err := os.Stat(filepath.Join(m.opts.Dir, name+sentSuffix))
+	return err == nil
+}
+
+// RunDue generates the last complete daily and/or weekly report when it does
+// not exist yet, and mails it if it has not been mailed, so a failed delivery
+// is retried on the next run. Each period is handled even if another fails;
+// the errors are joined. It returns the reports it generated.
+func (m *Manager) RunDue(periods []string, now time.Time) ([]Info, error) {
+	var done []Info
+	var errs []error
+	for _, period := range periods {
+		start, _, err := Bounds(period, LastComplete(period, now))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		name := fileName(period, start)
+		if _, err := os.Stat(filepath.Join(m.opts.Dir, name)); err != nil {
+			info, err := m.Generate(period, start, now)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s report: %w", period, err))
+				continue
+			}
+			done = append(done, info)
+		}
+
+		if m.opts.SMTP != nil && !m.Sent(name) {
+			if err := m.Send(name); err != nil {
+				errs = append(errs, fmt.Errorf("report %s not sent: %w", name, err))
+			}
+		}
+	}
+	return done, errors.Join(errs...)
+}
+
+func (m *Manager) rotate() error {
+	if m.opts.Keep <= 0 {
+		return nil
+	}
+	reports, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, r := range reports[min(m.opts.Keep, len(reports)):] {
+		path := filepath.Join(m.opts.Dir, r.Name)
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		if err := os.Remove(path + sentSuffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the stored reports, newest period first.
+func (m *Manager) List() ([]Info, error) {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if os.IsNotExist(err) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []Info{}
+	for _, e := range entries {
+		period, start, ok := parseName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		stat, err := e.Info()
+		if err != nil {
+			continue
+		}
+		_, end, _ := Bounds(period, start)
+		if end.After(stat.ModTime()) {
+			end = stat.ModTime().Truncate(time.Second)
+		}
+		reports = append(reports, Info{
+			Name: e.Name(), Period: period, Start: start, End: end,
+			Size: stat.Size(), CreatedAt: stat.ModTime(),
+		})
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if !reports[i].Start.Equal(reports[j].Start) {
+			return reports[i].Start.After(reports[j].Start)
+		}
+		return reports[i].Period < reports[j].Period
+	})
+	return reports, nil
+}
+
+// Path returns the file path of the named report. Only names produced by
+// Generate are accepted, so callers cannot escape the report directory.
+func (m *Manager) Path(name string) (string, error) {
+	if _, _, ok := parseName(name); !ok {
+		return "", fmt.Errorf("invalid report name %q", name)
+	}
+	path := filepath.Join(m.opts.Dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("report %q not found", name)
+	}
+	return path, nil
+}
+
+// fileName is e.g. report-daily-20240115.html; the date is the local start
+// of the period.
+func fileName(period string, start time.Time) string {
+	return filePrefix + period + "-" + start.Format(dateFormat) + fileSuffix
+}
+
+func parseName(name string) (string, time.Time, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return "", time.Time{}, false
+	}
+	period, date, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix), "-")
+	if !ok || (period != PeriodDaily && period != PeriodWeekly) {
+		return "", time.Time{}, false
+	}
+	start, err := time.ParseInLocation(dateFormat, date, time.Local)
+	return period, start, err == nil
+}
+
+func subject(period string, start time.Time) string {
+	if period == PeriodWeekly {
+		return "服务器监控周报 " + start.Format("2006-01-02")
+	}
+	return "服务器监控日报 " + start.Format("2006-01-02")
+}
+
+// activeServers returns the servers a report covers: all except archived
+// ones, sorted by name.
+func activeServers(db database.Store) ([]model.Server, error) {
+	all, err := db.GetServers()
+	if err != nil {
+		return nil, err
+	}
+	servers := make([]model.Server, 0, len(all))
+	for _, s := range all {
+		if s.Lifecycle != model.LifecycleArchived {
+			servers = append(servers, s)
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
@@ -0,0 +1,184 @@
+package report
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monitor-system/internal/server/database"
+)
+
+// smtpStub is a minimal SMTP server that records the messages it receives.
+type smtpStub struct {
+	ln       net.Listener
+	messages chan string
+}
+
+func startSMTP(t *testing.T) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{ln: ln, messages: make(chan string, 10)}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 stub ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, _, _ := strings.Cut(strings.ToUpper(line), " ")
+		switch verb {
+		case "EHLO":
+			tp.PrintfLine("250-stub")
+			tp.PrintfLine("250 8BITMIME")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.messages <- string(data)
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (s *smtpStub) config() *SMTP {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	return &SMTP{Host: "127.0.0.1", Port: addr.Port, From: "monitor@example.com", To: []string{"ops@example.com"}}
+}
+
+// closedPort returns a local port nothing listens on.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestSMTPSend(t *testing.T) {
+	stub := startSMTP(t)
+	body := []byte(strings.Repeat("<p>服务器日报</p>", 20))
+	if err := stub.config().Send("服务器日报 2024-01-15", body); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw string
+	select {
+	case raw = <-stub.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "服务器日报 2024-01-15" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if got := msg.Header.Get("To"); got != "ops@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if got := msg.Header.Get("Content-Type"); got != "text/html; charset=UTF-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != string(body) {
+		t.Errorf("body = %q, want %q", decoded, body)
+	}
+}
+
+func TestRunDueRetriesUnsent(t *testing.T) {
+	stub := startSMTP(t)
+	down := stub.config()
+	down.Port = closedPort(t)
+
+	m := New(database.NewMemory(), Options{Dir: t.TempDir(), Slot: 5 * time.Minute, SMTP: down})
+	periods := []string{PeriodDaily, PeriodWeekly}
+	now := time.Now()
+
+	// 邮件服务器不可用：两个报告都生成，但都未发送，错误按周期分别记录
+	done, err := m.RunDue(periods, now)
+	if err == nil {
+		t.Fatal("RunDue succeeded with the mail server down")
+	}
+	if len(done) != 2 {
+		t.Fatalf("generated %d reports, want 2 (error: %v)", len(done), err)
+	}
+	if n := strings.Count(err.Error(), "not sent"); n != 2 {
+		t.Errorf("error reports %d unsent reports, want 2: %v", n, err)
+	}
+	for _, info := range done {
+		if m.Sent(info.Name) {
+			t.Errorf("%s marked sent", info.Name)
+		}
+	}
+
+	// 恢复后重试发送，不重新生成
+	m.opts.SMTP = stub.config()
+	again, err := m.RunDue(periods, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 0 {
+		t.Errorf("regenerated %d reports", len(again))
+	}
+	for _, info := range done {
+		if !m.Sent(info.Name) {
+			t.Errorf("%s not marked sent", info.Name)
+		}
+	}
+	if n := len(stub.messages); n != 2 {
+		t.Errorf("received %d messages, want 2", n)
+	}
+
+	// 已发送的报告不再重复发送
+	if _, err := m.RunDue(periods, now); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(stub.messages); n != 2 {
+		t.Errorf("received %d messages after a third run, want 2", n)
+	}
+}
@@ -0,0 +1,28 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+//go:embed report.html
+var pageTemplate string
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.In(time.Local).Format("2006-01-02 15:04") },
+	"pct":  func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+	"pct3": func(v float64) string { return fmt.Sprintf("%.3f%%", v) },
+	"num":  func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	// 磁盘容量以 MB 上报
+	"size": func(mb uint64) string {
+		if mb < 1024 {
+			return fmt.Sprintf("%d MB", mb)
+		}
+		if mb < 1024*1024 {
+			return fmt.Sprintf("%.1f GB", float64(mb)/1024)
+		}
+		return fmt.Sprintf("%.1f TB", float64(mb)/1024/1024)
+	},
+}).Parse(pageTemplate))
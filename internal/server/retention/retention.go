@@ -0,0 +1,192 @@
+// Package retention deletes expired data according to per-kind policies with
+// per-server overrides, and reclaims the freed space.
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/monitor-system/internal/server/config"
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/selector"
+	"github.com/monitor-system/pkg/api"
+)
+
+// Result describes one cleanup run.
+type Result = api.CleanupResult
+
+// Policy is the configured policy as reported by the admin API. Retention is
+// in days; zero or absent means data is kept forever.
+type (
+	Policy         = api.RetentionPolicy
+	PolicyOverride = api.RetentionOverride
+)
+
+type override struct {
+	ids  map[string]bool
+	sel  selector.Selector
+	days map[string]int
+}
+
+func (o *override) matches(s model.Server) bool {
+	if len(o.ids) > 0 && !o.ids[s.ID] {
+		return false
+	}
+	return o.sel.Matches(s.Labels)
+}
+
+// Manager applies a retention policy to a store.
+type Manager struct {
+	db         database.Store
+	policy     Policy
+	days       map[string]int
+	overrides  []override
+	vacuum     string
+	interval   time.Duration
+	mu         sync.Mutex
+	last       *Result
+	lastVacuum time.Time
+}
+
+// New validates cfg and returns a Manager.
+func New(db database.Store, cfg config.RetentionConfig) (*Manager, error) {
+	kinds := make(map[string]bool)
+	for _, kind := range database.DataKinds() {
+		kinds[kind] = true
+	}
+	checkKinds := func(days map[string]int) error {
+		for kind := range days {
+			if !kinds[kind] {
+				return fmt.Errorf("unknown retention data kind %q, must be one of %v", kind, database.DataKinds())
+			}
+		}
+		return nil
+	}
+
+	if err := checkKinds(cfg.Days); err != nil {
+		return nil, err
+	}
+	switch cfg.Vacuum {
+	case "incremental", "full", "off":
+	default:
+		return nil, fmt.Errorf("invalid retention vacuum mode %q, must be incremental, full or off", cfg.Vacuum)
+	}
+
+	// 首次 VACUUM 在一个间隔之后执行，避免启动时长时间锁库
+	m := &Manager{
+		db:         db,
+		lastVacuum: time.Now(),
+		days:       cfg.Days,
+		vacuum:     cfg.Vacuum,
+		interval:   time.Duration(cfg.Interval) * time.Hour,
+		policy: Policy{
+			Days:           cfg.Days,
+			Overrides:      []PolicyOverride{},
+			Vacuum:         cfg.Vacuum,
+			VacuumInterval: (time.Duration(cfg.Interval) * time.Hour).String(),
+		},
+	}
+	for i, o := range cfg.Overrides {
+		if err := checkKinds(o.Days); err != nil {
+			return nil, err
+		}
+		if len(o.Servers) == 0 && o.Selector == "" {
+			return nil, fmt.Errorf("retention override %d needs servers or selector", i+1)
+		}
+		sel, err := selector.Parse(o.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("retention override %d: %w", i+1, err)
+		}
+		ids := make(map[string]bool, len(o.Servers))
+		for _, id := range o.Servers {
+			ids[id] = true
+		}
+		m.overrides = append(m.overrides, override{ids: ids, sel: sel, days: o.Days})
+		m.policy.Overrides = append(m.policy.Overrides, PolicyOverride{Servers: o.Servers, Selector: o.Selector, Days: o.Days})
+	}
+	return m, nil
+}
+
+func (m *Manager) Policy() Policy {
+	return m.policy
+}
+
+// Run deletes expired data and, when due, vacuums the database.
+func (m *Manager) Run() Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := time.Now()
+	result := Result{StartedAt: start, Deleted: map[string]int64{}}
+	if err := m.prune(start, result.Deleted); err != nil {
+		result.Error = err.Error()
+	} else if m.vacuum != "off" && start.Sub(m.lastVacuum) >= m.interval {
+		if err := m.db.Vacuum(m.vacuum == "full"); err != nil {
+			result.Error = "vacuum: " + err.Error()
+		} else {
+			result.Vacuumed = true
+			m.lastVacuum = start
+		}
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+
+	m.last = &result
+	return result
+}
+
+// LastResult returns the most recent run, or nil before the first one.
+func (m *Manager) LastResult() *Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+func (m *Manager) prune(now time.Time, deleted map[string]int64) error {
+	servers, err := m.db.GetServers()
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range database.DataKinds() {
+		// 按覆盖规则把服务器分组，其余服务器使用默认策略
+		groups := make(map[int][]string)
+		var overridden []string
+		for _, s := range servers {
+			for _, o := range m.overrides {
+				if days, ok := o.days[kind]; ok && o.matches(s) {
+					groups[days] = append(groups[days], s.ID)
+					overridden = append(overridden, s.ID)
+					break
+				}
+			}
+		}
+
+		if days := m.days[kind]; days > 0 {
+			n, err := m.db.Prune(kind, now.AddDate(0, 0, -days), nil, overridden)
+			if err != nil {
+				return fmt.Errorf("prune %s: %w", kind, err)
+			}
+			deleted[kind] += n
+		}
+
+		keys := make([]int, 0, len(groups))
+		for days := range groups {
+			keys = append(keys, days)
+		}
+		sort.Ints(keys)
+		for _, days := range keys {
+			if days <= 0 {
+				continue // 永久保留
+			}
+			n, err := m.db.Prune(kind, now.AddDate(0, 0, -days), groups[days], nil)
+			if err != nil {
+				return fmt.Errorf("prune %s: %w", kind, err)
+			}
+			deleted[kind] += n
+		}
+	}
+	return nil
+}
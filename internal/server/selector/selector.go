@@ -0,0 +1,167 @@
+// Package selector parses and evaluates label selectors such as
+// "env=prod,role!=db,gpu".
+package selector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/monitor-system/internal/server/model"
+)
+
+const (
+	OpEquals    = "="
+	OpNotEquals = "!="
+	OpExists    = "exists"
+	OpNotExists = "!exists"
+)
+
+// Requirement is one comma-separated term of a selector.
+type Requirement struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// Selector matches a server when all of its requirements hold. An empty
+// selector matches everything.
+type Selector []Requirement
+
+// Parse accepts comma-separated terms: "key=value" (or "=="), "key!=value",
+// "key" (label present) and "!key" (label absent).
+func Parse(s string) (Selector, error) {
+	var sel Selector
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var r Requirement
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			r = Requirement{Key: parts[0], Op: OpNotEquals, Value: parts[1]}
+		case strings.Contains(term, "=="):
+			parts := strings.SplitN(term, "==", 2)
+			r = Requirement{Key: parts[0], Op: OpEquals, Value: parts[1]}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			r = Requirement{Key: parts[0], Op: OpEquals, Value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			r = Requirement{Key: term[1:], Op: OpNotExists}
+		default:
+			r = Requirement{Key: term, Op: OpExists}
+		}
+
+		r.Key = strings.TrimSpace(r.Key)
+		r.Value = strings.TrimSpace(r.Value)
+		if !validKey(r.Key) {
+			return nil, fmt.Errorf("invalid label selector term %q", term)
+		}
+		sel = append(sel, r)
+	}
+
+	return sel, nil
+}
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether labels satisfy every requirement.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		value, ok := labels[r.Key]
+		switch r.Op {
+		case OpEquals:
+			if !ok || value != r.Value {
+				return false
+			}
+		case OpNotEquals:
+			if ok && value == r.Value {
+				return false
+			}
+		case OpExists:
+			if !ok {
+				return false
+			}
+		case OpNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	terms := make([]string, 0, len(s))
+	for _, r := range s {
+		switch r.Op {
+		case OpExists:
+			terms = append(terms, r.Key)
+		case OpNotExists:
+			terms = append(terms, "!"+r.Key)
+		default:
+			terms = append(terms, r.Key+r.Op+r.Value)
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// Select picks servers from all. When ids is non-empty, the listed servers
+// are returned in that order (an unknown ID is an error) and filtered by sel;
+// otherwise every server matching sel is returned, sorted by name.
+func Select(all []model.Server, ids []string, sel Selector) ([]model.Server, error) {
+	var servers []model.Server
+	if len(ids) > 0 {
+		byID := make(map[string]model.Server, len(all))
+		for _, s := range all {
+			byID[s.ID] = s
+		}
+		seen := make(map[string]bool)
+		for _, id := range ids {
+			s, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("server %q not found", id)
+			}
+			if !seen[id] && sel.Matches(s.Labels) {
+				servers = append(servers, s)
+			}
+			seen[id] = true
+		}
+		return servers, nil
+	}
+
+	for _, s := range all {
+		if sel.Matches(s.Labels) {
+			servers = append(servers, s)
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
+
+// SplitIDs splits a comma-separated list of server IDs, dropping blanks.
+func SplitIDs(list string) []string {
+	var ids []string
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
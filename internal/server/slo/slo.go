@@ -0,0 +1,301 @@
+// Package slo computes service level indicators, error budgets and burn
+// rates per server from the stored metrics history.
+package slo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/internal/server/config"
+	"github.com/monitor-system/internal/server/database"
+	"github.com/monitor-system/internal/server/maintenance"
+	"github.com/monitor-system/internal/server/model"
+	"github.com/monitor-system/internal/server/query"
+	"github.com/monitor-system/internal/server/selector"
+	"github.com/monitor-system/pkg/api"
+)
+
+const (
+	TypeAvailability = api.SLOAvailability
+	TypeMetric       = api.SLOMetric
+)
+
+// Objective is a validated SLO definition.
+type Objective struct {
+	api.SLOObjective
+
+	sel selector.Selector
+}
+
+// Applies reports whether the objective covers server s. Archived servers
+// are only covered when listed explicitly.
+func (o *Objective) Applies(s *model.Server) bool {
+	if len(o.Servers) > 0 {
+		found := false
+		for _, id := range o.Servers {
+			if id == s.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	} else if s.Lifecycle == model.LifecycleArchived {
+		return false
+	}
+	return o.sel.Matches(s.Labels)
+}
+
+func (o *Objective) good(m *model.Metrics) bool {
+	v := query.Metrics[o.Metric](m)
+	if o.Comparison == "above" {
+		return v > o.Threshold
+	}
+	return v < o.Threshold
+}
+
+// Reporter evaluates the configured objectives against stored history.
+type Reporter struct {
+	db         database.Store
+	objectives []Objective
+	slot       time.Duration
+}
+
+// New validates the objectives in cfg.
+func New(db database.Store, cfg config.SLOConfig) (*Reporter, error) {
+	objectives, err := newObjectives(cfg.Objectives)
+	if err != nil {
+		return nil, err
+	}
+	return &Reporter{db: db, objectives: objectives, slot: time.Duration(cfg.Slot) * time.Second}, nil
+}
+
+// Objectives returns the configured objectives.
+func (r *Reporter) Objectives() []Objective {
+	return r.objectives
+}
+
+// Report holds the results of every objective for one calendar month.
+type Report = api.SLOReport
+
+// ParseMonth parses "YYYY-MM" in local time; an empty string is the current
+// month.
+func ParseMonth(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local), nil
+	}
+	t, err := time.ParseInLocation("2006-01", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, use YYYY-MM", s)
+	}
+	if t.After(now) {
+		return time.Time{}, fmt.Errorf("month %s is in the future", s)
+	}
+	return t, nil
+}
+
+// Report computes the objectives over the month starting at month for the
+// given servers. The current month is evaluated up to now, so burn rates
+// show how fast its budget is being spent.
+func (r *Reporter) Report(month time.Time, servers []model.Server, now time.Time) (*Report, error) {
+	end := month.AddDate(0, 1, 0)
+	if end.After(now) {
+		end = now.Truncate(r.slot)
+	}
+	results, err := Compute(r.db, r.objectives, servers, month, end, r.slot)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{Month: month.Format("2006-01"), Start: month, End: end, Results: results}, nil
+}
+
+func newObjectives(cfg []config.SLOObjective) ([]Objective, error) {
+	objectives := make([]Objective, 0, len(cfg))
+	seen := make(map[string]bool)
+	for i, c := range cfg {
+		o := Objective{SLOObjective: api.SLOObjective{
+			Name: strings.TrimSpace(c.Name), Type: c.Type, Target: c.Target,
+			Metric: c.Metric, Threshold: c.Threshold, Comparison: c.Comparison,
+			Servers: c.Servers, Selector: c.Selector,
+		}}
+		if o.Name == "" {
+			return nil, fmt.Errorf("slo objective %d: name is required", i+1)
+		}
+		if seen[o.Name] {
+			return nil, fmt.Errorf("slo objective %q defined twice", o.Name)
+		}
+		seen[o.Name] = true
+		if o.Target <= 0 || o.Target >= 100 {
+			return nil, fmt.Errorf("slo objective %q: target must be between 0 and 100", o.Name)
+		}
+
+		switch o.Type {
+		case TypeAvailability:
+		case TypeMetric:
+			if _, ok := query.Metrics[o.Metric]; !ok {
+				return nil, fmt.Errorf("slo objective %q: unknown metric %q", o.Name, o.Metric)
+			}
+			if o.Comparison == "" {
+				o.Comparison = "below"
+			}
+			if o.Comparison != "below" && o.Comparison != "above" {
+				return nil, fmt.Errorf("slo objective %q: comparison must be below or above", o.Name)
+			}
+		default:
+			return nil, fmt.Errorf("slo objective %q: type must be availability or metric", o.Name)
+		}
+
+		sel, err := selector.Parse(o.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("slo objective %q: %w", o.Name, err)
+		}
+		o.sel = sel
+		objectives = append(objectives, o)
+	}
+	return objectives, nil
+}
+
+// Result is the outcome of one objective for one server over a period.
+type Result = api.SLOResult
+
+// finish derives the SLI, budget and burn rate from the counts.
+func finish(r *Result) {
+	if r.Total == 0 {
+		r.NoData = true
+		return
+	}
+	r.SLI = float64(r.Good) / float64(r.Total) * 100
+	r.Met = r.SLI >= r.Target
+	budget := 100 - r.Target
+	errorRate := 100 - r.SLI
+	r.BudgetRemaining = (budget - errorRate) / budget * 100
+	r.BurnRate = errorRate / budget
+}
+
+// Compute evaluates every objective for the servers it applies to over
+// [start, end). Availability is the share of slot-wide intervals with at
+// least one metrics sample; metric objectives count good samples. Time a
+// server spends in a maintenance window, or before it was first seen, is
+// left out.
+func Compute(db database.Store, objectives []Objective, servers []model.Server,
+	start, end time.Time, slot time.Duration) ([]Result, error) {
+
+	windows, err := db.GetMaintenanceWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	type target struct {
+		server *model.Server
+		result *Result
+		obj    *Objective
+	}
+	byServer := make(map[string][]target)
+	var results []*Result
+	var ids []string
+	for i := range servers {
+		s := &servers[i]
+		for j := range objectives {
+			o := &objectives[j]
+			if !o.Applies(s) {
+				continue
+			}
+			r := &Result{Objective: o.Name, Type: o.Type, ServerID: s.ID, ServerName: s.Name, Target: o.Target}
+			if len(byServer[s.ID]) == 0 {
+				ids = append(ids, s.ID)
+			}
+			byServer[s.ID] = append(byServer[s.ID], target{server: s, result: r, obj: o})
+			results = append(results, r)
+		}
+	}
+	if len(ids) == 0 {
+		return []Result{}, nil
+	}
+
+	// 每台服务器只匹配一次维护窗口，逐个时间点只判断是否落在窗口内
+	applicable := make(map[string][]model.MaintenanceWindow)
+	if len(windows) > 0 {
+		for _, id := range ids {
+			applicable[id] = maintenance.Applicable(windows, byServer[id][0].server)
+		}
+	}
+	inMaintenance := func(s *model.Server, t time.Time) bool {
+		ws := applicable[s.ID]
+		for i := range ws {
+			if maintenance.Active(&ws[i], t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 每台服务器记录有数据的时间片
+	slots := int(end.Sub(start) / slot)
+	seen := make(map[string][]bool)
+	err = db.EachMetric(ids, start, end, func(m *model.Metrics) error {
+		targets := byServer[m.ServerID]
+		if len(targets) == 0 || inMaintenance(targets[0].server, m.Timestamp) {
+			return nil
+		}
+		for _, t := range targets {
+			switch t.obj.Type {
+			case TypeAvailability:
+				if seen[m.ServerID] == nil {
+					seen[m.ServerID] = make([]bool, slots)
+				}
+				if i := int(m.Timestamp.Sub(start) / slot); i >= 0 && i < slots {
+					seen[m.ServerID][i] = true
+				}
+			case TypeMetric:
+				t.result.Total++
+				if t.obj.good(m) {
+					t.result.Good++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		for _, t := range byServer[id] {
+			if t.obj.Type != TypeAvailability {
+				continue
+			}
+			for i := 0; i < slots; i++ {
+				at := start.Add(time.Duration(i) * slot)
+				if !at.Add(slot).After(t.server.CreatedAt) || inMaintenance(t.server, at) {
+					continue
+				}
+				t.result.Total++
+				if seen[id] != nil && seen[id][i] {
+					t.result.Good++
+				}
+			}
+		}
+	}
+
+	out := make([]Result, len(results))
+	for i, r := range results {
+		finish(r)
+		out[i] = *r
+	}
+	return out, nil
+}
+
+// Availability computes the heartbeat availability of each server over
+// [start, end) the same way availability objectives do, measured against
+// target.
+func Availability(db database.Store, servers []model.Server, start, end time.Time,
+	slot time.Duration, target float64) ([]Result, error) {
+
+	o := Objective{SLOObjective: api.SLOObjective{Name: TypeAvailability, Type: TypeAvailability, Target: target}}
+	for _, s := range servers {
+		o.Servers = append(o.Servers, s.ID)
+	}
+	return Compute(db, []Objective{o}, servers, start, end, slot)
+}
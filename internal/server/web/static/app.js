@@ -0,0 +1,376 @@
+// 内置监控面板：纯静态页面，通过 /api/v1 接口读取数据，API Key 保存在 localStorage。
+(function () {
+  'use strict';
+
+  var KEY_STORAGE = 'monitor.apiKey';
+  var REFRESH_MS = 10000;
+  var app = document.getElementById('app');
+  var timer = null;
+
+  var STATUS_TEXT = { online: '在线', offline: '离线', warning: '告警', maintenance: '维护中' };
+
+  // ---- API ----
+
+  function apiKey() { return localStorage.getItem(KEY_STORAGE); }
+
+  function api(path, options) {
+    options = options || {};
+    options.headers = { 'X-API-Key': apiKey() };
+    return fetch('../api/v1' + path, options).then(function (res) {
+      if (res.status === 401) {
+        logout();
+        throw new Error('unauthorized');
+      }
+      return res.json().then(function (body) {
+        if (!res.ok) throw new Error(body.error || res.statusText);
+        return body;
+      });
+    });
+  }
+
+  function logout() {
+    localStorage.removeItem(KEY_STORAGE);
+    location.hash = '#/login';
+  }
+
+  // ---- helpers ----
+
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      if (k === 'text') node.textContent = attrs[k];
+      else if (k === 'className') node.className = attrs[k];
+      else node.setAttribute(k, attrs[k]);
+    });
+    (children || []).forEach(function (c) { if (c) node.appendChild(c); });
+    return node;
+  }
+
+  function td(text, cls) { return el('td', { text: text, className: cls || '' }); }
+  function num(v, digits) { return v == null ? '-' : Number(v).toFixed(digits == null ? 1 : digits); }
+  function pct(v) { return num(v) + '%'; }
+
+  function size(mb) {
+    if (mb < 1024) return mb + ' MB';
+    if (mb < 1024 * 1024) return (mb / 1024).toFixed(1) + ' GB';
+    return (mb / 1024 / 1024).toFixed(1) + ' TB';
+  }
+
+  function ago(ts) {
+    if (!ts) return '-';
+    var s = Math.max(0, Math.round((Date.now() - new Date(ts)) / 1000));
+    if (s < 60) return s + ' 秒前';
+    if (s < 3600) return Math.floor(s / 60) + ' 分钟前';
+    if (s < 86400) return Math.floor(s / 3600) + ' 小时前';
+    return Math.floor(s / 86400) + ' 天前';
+  }
+
+  function statusBadge(status) {
+    return el('span', { className: 'status ' + status, text: STATUS_TEXT[status] || status });
+  }
+
+  function bar(v) {
+    var b = el('span', { className: 'bar' + (v >= 90 ? ' high' : '') }, [el('span')]);
+    b.firstChild.style.width = Math.min(100, Math.max(0, v)) + '%';
+    return b;
+  }
+
+  function card(value, label) {
+    return el('div', { className: 'card' }, [
+      el('div', { className: 'value', text: value }),
+      el('div', { className: 'label', text: label })
+    ]);
+  }
+
+  function fill(tbody, rows, empty, cols) {
+    tbody.innerHTML = '';
+    if (!rows.length) {
+      tbody.appendChild(el('tr', {}, [el('td', { className: 'muted', colspan: cols, text: empty })]));
+      return;
+    }
+    rows.forEach(function (r) { tbody.appendChild(r); });
+  }
+
+  function render(id) {
+    app.innerHTML = '';
+    app.appendChild(document.getElementById(id).content.cloneNode(true));
+  }
+
+  function refreshed() {
+    document.getElementById('refreshed').textContent = '更新于 ' + new Date().toLocaleTimeString();
+  }
+
+  function fail(err) {
+    if (err.message === 'unauthorized') return;
+    document.getElementById('refreshed').textContent = '加载失败：' + err.message;
+  }
+
+  // ---- chart ----
+
+  var SVG_NS = 'http://www.w3.org/2000/svg';
+
+  function svg(tag, attrs) {
+    var node = document.createElementNS(SVG_NS, tag);
+    Object.keys(attrs).forEach(function (k) { node.setAttribute(k, attrs[k]); });
+    return node;
+  }
+
+  // series: [{name, color, points: [[Date, value], ...]}]
+  function lineChart(container, series, unit) {
+    var W = 900, H = 260, L = 50, R = 10, T = 20, B = 24;
+    var all = [];
+    series.forEach(function (s) { all = all.concat(s.points); });
+    container.innerHTML = '';
+    if (!all.length) {
+      container.appendChild(el('p', { className: 'muted', text: '该时间段没有数据' }));
+      return;
+    }
+
+    var t0 = Math.min.apply(null, all.map(function (p) { return +p[0]; }));
+    var t1 = Math.max.apply(null, all.map(function (p) { return +p[0]; }));
+    if (t1 === t0) t1 = t0 + 1;
+    var yMax = unit === '%' ? 100 : Math.max.apply(null, all.map(function (p) { return p[1]; }));
+    if (!(yMax > 0)) yMax = 1;
+
+    var x = function (t) { return L + (W - L - R) * (t - t0) / (t1 - t0); };
+    var y = function (v) { return T + (H - T - B) * (1 - v / yMax); };
+    var root = svg('svg', { viewBox: '0 0 ' + W + ' ' + H, 'font-size': 11, 'font-family': 'sans-serif' });
+
+    for (var i = 0; i <= 4; i++) {
+      var v = yMax * i / 4;
+      root.appendChild(svg('line', { x1: L, x2: W - R, y1: y(v), y2: y(v), stroke: '#e5e7eb' }));
+      var label = svg('text', { x: L - 6, y: y(v) + 4, 'text-anchor': 'end', fill: '#6b7280' });
+      label.textContent = (yMax >= 10 ? v.toFixed(0) : v.toFixed(2)) + unit;
+      root.appendChild(label);
+    }
+
+    var span = t1 - t0;
+    for (var j = 0; j <= 6; j++) {
+      var t = new Date(t0 + span * j / 6);
+      var text = span > 86400000 * 2
+        ? (t.getMonth() + 1) + '-' + t.getDate() + ' ' + t.getHours() + ':00'
+        : t.toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
+      var tick = svg('text', { x: x(+t), y: H - 6, 'text-anchor': 'middle', fill: '#6b7280' });
+      tick.textContent = text;
+      root.appendChild(tick);
+    }
+
+    series.forEach(function (s, k) {
+      // 两点间隔超过中位间隔的 5 倍时断开曲线，避免把离线时段连起来
+      var gaps = [];
+      for (var n = 1; n < s.points.length; n++) gaps.push(s.points[n][0] - s.points[n - 1][0]);
+      gaps.sort(function (a, b) { return a - b; });
+      var limit = gaps.length ? gaps[Math.floor(gaps.length / 2)] * 5 : Infinity;
+
+      var segment = [];
+      var flush = function () {
+        if (segment.length) {
+          root.appendChild(svg('polyline', { fill: 'none', stroke: s.color, 'stroke-width': 1.5, points: segment.join(' ') }));
+        }
+        segment = [];
+      };
+      s.points.forEach(function (p, idx) {
+        if (idx > 0 && p[0] - s.points[idx - 1][0] > limit) flush();
+        segment.push(x(+p[0]).toFixed(1) + ',' + y(p[1]).toFixed(1));
+      });
+      flush();
+
+      root.appendChild(svg('rect', { x: L + 10 + k * 90, y: 4, width: 10, height: 10, fill: s.color }));
+      var name = svg('text', { x: L + 24 + k * 90, y: 13, fill: '#111827' });
+      name.textContent = s.name;
+      root.appendChild(name);
+    });
+
+    container.appendChild(root);
+  }
+
+  // ---- views ----
+
+  function showLogin() {
+    render('login-view');
+    document.getElementById('logout').hidden = true;
+    var form = document.getElementById('login');
+    form.addEventListener('submit', function (e) {
+      e.preventDefault();
+      var key = form.key.value.trim();
+      fetch('../api/v1/auth/verify', { method: 'POST', headers: { 'X-API-Key': key } }).then(function (res) {
+        if (!res.ok) throw new Error(res.status === 401 ? 'API Key 无效' : res.statusText);
+        localStorage.setItem(KEY_STORAGE, key);
+        location.hash = '#/';
+      }).catch(function (err) {
+        var p = form.querySelector('.error');
+        p.textContent = err.message;
+        p.hidden = false;
+      });
+    });
+  }
+
+  function showList() {
+    render('list-view');
+    var state = { sort: 'name', order: 'asc' };
+    var search = document.getElementById('search');
+    var status = document.getElementById('status');
+    var headers = app.querySelectorAll('th[data-sort]');
+
+    function load() {
+      var params = new URLSearchParams({ sort: state.sort, order: state.order });
+      if (search.value.trim()) params.set('search', search.value.trim());
+      if (status.value) params.set('status', status.value);
+
+      Promise.all([api('/servers?' + params), api('/overview')]).then(function (res) {
+        var servers = res[0].servers, ov = res[1].overview;
+        var summary = document.getElementById('summary');
+        summary.innerHTML = '';
+        summary.appendChild(card(ov.total, '服务器'));
+        summary.appendChild(card(ov.byStatus.online || 0, '在线'));
+        summary.appendChild(card(ov.byStatus.offline || 0, '离线'));
+        summary.appendChild(card(pct(ov.cpu.avg), '平均 CPU'));
+        summary.appendChild(card(pct(ov.memory.avg), '平均内存'));
+        summary.appendChild(card((ov.fullDisks || []).length, '磁盘 ≥ ' + ov.diskThreshold + '%'));
+
+        fill(app.querySelector('table.servers tbody'), servers.map(function (s) {
+          var m = s.currentMetrics || {};
+          var row = el('tr', {}, [
+            td(s.name), el('td', {}, [statusBadge(s.status)]), td(s.ip),
+            td(s.currentMetrics ? pct(m.cpu) : '-', 'num'), td(s.currentMetrics ? pct(m.memory) : '-', 'num'),
+            td(s.currentMetrics ? num(m.upload, 2) : '-', 'num'), td(s.currentMetrics ? num(m.download, 2) : '-', 'num'),
+            td(ago(s.lastHeartbeat))
+          ]);
+          row.addEventListener('click', function () { location.hash = '#/servers/' + encodeURIComponent(s.id); });
+          return row;
+        }), '没有服务器', 8);
+        document.getElementById('count').textContent = '共 ' + res[0].total + ' 台';
+        refreshed();
+      }).catch(fail);
+    }
+
+    headers.forEach(function (th) {
+      th.addEventListener('click', function () {
+        var field = th.getAttribute('data-sort');
+        state.order = state.sort === field && state.order === 'asc' ? 'desc' : 'asc';
+        state.sort = field;
+        headers.forEach(function (h) { h.classList.remove('asc', 'desc'); });
+        th.classList.add(state.order);
+        load();
+      });
+    });
+    headers[0].classList.add('asc');
+
+    var debounce;
+    search.addEventListener('input', function () { clearTimeout(debounce); debounce = setTimeout(load, 300); });
+    status.addEventListener('change', load);
+    load();
+    return load;
+  }
+
+  function showDetail(id) {
+    render('detail-view');
+    var base = '/servers/' + encodeURIComponent(id);
+    var metric = document.getElementById('metric');
+    var duration = document.getElementById('duration');
+    var procSort = document.getElementById('proc-sort');
+
+    function loadChart() {
+      api(base + '/history?duration=' + duration.value).then(function (res) {
+        var pts = function (f) { return res.history.map(function (h) { return [new Date(h.timestamp), f(h)]; }); };
+        var series, unit = '%';
+        switch (metric.value) {
+          case 'memory':
+            series = [{ name: '内存', color: '#16a34a', points: pts(function (h) { return h.memory; }) }];
+            break;
+          case 'network':
+            unit = '';
+            series = [
+              { name: '上行', color: '#2563eb', points: pts(function (h) { return h.networkOut; }) },
+              { name: '下行', color: '#f59e0b', points: pts(function (h) { return h.networkIn; }) }
+            ];
+            break;
+          case 'disk':
+            unit = '';
+            series = [
+              { name: '读取', color: '#2563eb', points: pts(function (h) { return h.diskRead; }) },
+              { name: '写入', color: '#f59e0b', points: pts(function (h) { return h.diskWrite; }) }
+            ];
+            break;
+          default:
+            series = [{ name: 'CPU', color: '#2563eb', points: pts(function (h) { return h.cpu; }) }];
+        }
+        lineChart(document.getElementById('chart'), series, unit);
+      }).catch(fail);
+    }
+
+    function loadProcesses() {
+      api(base + '/processes?limit=20&sortBy=' + procSort.value).then(function (res) {
+        fill(document.querySelector('#processes tbody'), res.processes.map(function (p) {
+          return el('tr', {}, [td(p.pid, 'num'), td(p.name), td(p.user), td(p.status), td(pct(p.cpu), 'num'), td(pct(p.memory), 'num')]);
+        }), '暂无数据', 6);
+      }).catch(fail);
+    }
+
+    function load() {
+      api(base).then(function (res) {
+        var s = res.server, m = s.metrics || {}, info = s.info || {}, inv = s.inventory || {};
+        var title = document.getElementById('title');
+        title.textContent = s.name + ' ';
+        title.appendChild(statusBadge(s.status));
+
+        var cards = document.getElementById('cards');
+        cards.innerHTML = '';
+        cards.appendChild(card(s.ip || '-', 'IP'));
+        cards.appendChild(card(inv.platform ? inv.platform + ' ' + (inv.platformVersion || '') : s.os, '系统'));
+        cards.appendChild(card(s.metrics ? pct(m.cpu) : '-', 'CPU' + (info.cpuCores ? '（' + info.cpuCores + ' 核）' : '')));
+        cards.appendChild(card(s.metrics ? pct(m.memory) : '-', '内存' + (info.totalMemory ? '（' + size(info.totalMemory) + '）' : '')));
+        cards.appendChild(card(s.metrics ? num(m.networkOut, 2) + ' / ' + num(m.networkIn, 2) : '-', '上行 / 下行 (MB/s)'));
+        cards.appendChild(card(info.uptime ? Math.floor(info.uptime / 86400) + ' 天' : '-', '运行时间'));
+        refreshed();
+      }).catch(fail);
+
+      api(base + '/disks').then(function (res) {
+        fill(document.querySelector('#disks tbody'), res.disks.map(function (d) {
+          return el('tr', {}, [td(d.mountPoint), td(d.name), td(d.fsType), td(size(d.usedSize), 'num'),
+            td(size(d.totalSize), 'num'), el('td', {}, [bar(d.usagePercent), document.createTextNode(' ' + pct(d.usagePercent))])]);
+        }), '暂无数据', 6);
+      }).catch(fail);
+
+      api(base + '/network').then(function (res) {
+        fill(document.querySelector('#network tbody'), res.interfaces.map(function (n) {
+          return el('tr', {}, [td(n.name), td(n.type), td(n.status), td(num(n.uploadSpeed, 2), 'num'),
+            td(num(n.downloadSpeed, 2), 'num'), td(num(n.totalUpload, 0), 'num'), td(num(n.totalDownload, 0), 'num')]);
+        }), '暂无数据', 7);
+      }).catch(fail);
+
+      loadProcesses();
+      loadChart();
+    }
+
+    metric.addEventListener('change', loadChart);
+    duration.addEventListener('change', loadChart);
+    procSort.addEventListener('change', loadProcesses);
+    load();
+    return load;
+  }
+
+  // ---- routing ----
+
+  function route() {
+    clearInterval(timer);
+    var hash = location.hash.replace(/^#/, '') || '/';
+    if (!apiKey() && hash !== '/login') {
+      location.hash = '#/login';
+      return;
+    }
+    document.getElementById('logout').hidden = hash === '/login';
+
+    var refresh;
+    var match = hash.match(/^\/servers\/(.+)$/);
+    if (hash === '/login') showLogin();
+    else if (match) refresh = showDetail(decodeURIComponent(match[1]));
+    else refresh = showList();
+
+    if (refresh) timer = setInterval(refresh, REFRESH_MS);
+  }
+
+  document.getElementById('logout').addEventListener('click', logout);
+  window.addEventListener('hashchange', route);
+  route();
+})();
@@ -0,0 +1,24 @@
+// Package web embeds the browser dashboard served under /ui. It is a static
+// single-page app that talks to the /api/v1 endpoints with the user's API
+// key, so it needs no build step or separate deployment.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static
+var static embed.FS
+
+// Register serves the dashboard at prefix (e.g. "/ui").
+func Register(r *gin.Engine, prefix string) {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	r.StaticFS(prefix, http.FS(files))
+}
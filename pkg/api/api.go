@@ -0,0 +1,211 @@
+// Package api defines the JSON documents exchanged with the monitor server's
+// REST API under /api/v1. The server handlers encode these types directly,
+// so a client decoding them sees exactly what the server sends.
+package api
+
+import "time"
+
+// Error is the body of every non-2xx response.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// SuccessResponse acknowledges requests that return no document.
+type SuccessResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// CurrentMetrics is the latest sample shown in the server list.
+type CurrentMetrics struct {
+	CPU      float64 `json:"cpu"`
+	Memory   float64 `json:"memory"`
+	Network  float64 `json:"network"`
+	Upload   float64 `json:"upload"`   // 上行速度 (MB/s)
+	Download float64 `json:"download"` // 下行速度 (MB/s)
+}
+
+type ServerListItem struct {
+	Server
+	CurrentMetrics *CurrentMetrics `json:"currentMetrics,omitempty"`
+}
+
+// ServerListResponse is returned by GET /servers.
+type ServerListResponse struct {
+	Servers []ServerListItem `json:"servers"`
+	Total   int              `json:"total"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+}
+
+// ServerDetail is a server with its latest metrics, resources and inventory.
+type ServerDetail struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	IP        string            `json:"ip"`
+	Status    string            `json:"status"`
+	OS        string            `json:"os"`
+	Location  string            `json:"location"`
+	Metrics   *LatestMetrics    `json:"metrics,omitempty"`
+	Info      *ServerDetailInfo `json:"info,omitempty"`
+	Inventory *Inventory        `json:"inventory,omitempty"`
+}
+
+type LatestMetrics struct {
+	CPU        float64 `json:"cpu"`
+	Memory     float64 `json:"memory"`
+	DiskRead   float64 `json:"diskRead"`
+	DiskWrite  float64 `json:"diskWrite"`
+	NetworkIn  float64 `json:"networkIn"`
+	NetworkOut float64 `json:"networkOut"`
+}
+
+type ServerDetailInfo struct {
+	CPUCores    int   `json:"cpuCores"`
+	TotalMemory int64 `json:"totalMemory"` // MB
+	UsedMemory  int64 `json:"usedMemory"`  // MB
+	Uptime      int64 `json:"uptime"`      // 秒
+}
+
+// ServerDetailResponse is returned by GET /servers/:id.
+type ServerDetailResponse struct {
+	Server ServerDetail `json:"server"`
+}
+
+// ServerResponse is returned when a server's lifecycle changes.
+type ServerResponse struct {
+	Server *Server `json:"server"`
+}
+
+// HistoryResponse is returned by GET /servers/:id/history.
+type HistoryResponse struct {
+	History    []Metrics `json:"history"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Resolution int64     `json:"resolution"` // 聚合步长（秒），0 表示原始数据
+}
+
+type DisksResponse struct {
+	Disks []Disk `json:"disks"`
+}
+
+type ProcessesResponse struct {
+	Processes []Process `json:"processes"`
+}
+
+type NetworkResponse struct {
+	Interfaces []NetworkInterface `json:"interfaces"`
+}
+
+type AgentStatusResponse struct {
+	Agent *AgentStatus `json:"agent"`
+}
+
+type SensorsResponse struct {
+	Sensors    []Sensor       `json:"sensors"`
+	History    []SensorSeries `json:"history"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	Resolution int64          `json:"resolution"` // 秒
+}
+
+type InventoryResponse struct {
+	Inventory []Inventory `json:"inventory"`
+}
+
+// PackagesResponse lists the packages installed on one server.
+type PackagesResponse struct {
+	Packages []Package `json:"packages"`
+}
+
+// PackageSearchResponse is returned by GET /packages.
+type PackageSearchResponse struct {
+	Packages []ServerPackage `json:"packages"`
+}
+
+type PackageHistoryResponse struct {
+	Changes []PackageChange `json:"changes"`
+	Start   time.Time       `json:"start"`
+	End     time.Time       `json:"end"`
+}
+
+type AnomaliesResponse struct {
+	Anomalies []Anomaly `json:"anomalies"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+}
+
+type OverviewResponse struct {
+	Overview *Overview `json:"overview"`
+}
+
+type MaintenanceWindowsResponse struct {
+	Windows []MaintenanceWindowStatus `json:"windows"`
+}
+
+type MaintenanceWindowResponse struct {
+	Window MaintenanceWindow `json:"window"`
+}
+
+type BlockedServersResponse struct {
+	Blocked []BlockedServer `json:"blocked"`
+}
+
+// BlockServerRequest is the body of POST /blocked.
+type BlockServerRequest struct {
+	ServerID string `json:"serverId" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+type IngestStatsResponse struct {
+	Ingest IngestStats `json:"ingest"`
+}
+
+type SLOObjectivesResponse struct {
+	Objectives []SLOObjective `json:"objectives"`
+}
+
+type ReportsResponse struct {
+	Reports []ReportInfo `json:"reports"`
+}
+
+// ReportResponse is returned by POST /reports. Error is set when the report
+// was generated but could not be mailed.
+type ReportResponse struct {
+	Report  ReportInfo `json:"report"`
+	Emailed bool       `json:"emailed"`
+	Error   string     `json:"error,omitempty"`
+}
+
+type BackupsResponse struct {
+	Backups []Backup `json:"backups"`
+}
+
+type BackupResponse struct {
+	Backup Backup `json:"backup"`
+}
+
+// StorageResponse is returned by GET /admin/storage. LastCleanup is nil
+// until the first cleanup has run.
+type StorageResponse struct {
+	Storage     *StorageStats   `json:"storage"`
+	Retention   RetentionPolicy `json:"retention"`
+	LastCleanup *CleanupResult  `json:"lastCleanup"`
+}
+
+// CleanupResponse is returned by POST /admin/cleanup, also when the cleanup
+// failed part way.
+type CleanupResponse struct {
+	Cleanup CleanupResult `json:"cleanup"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// AgentReportResponse acknowledges POST /agent/report. Reports are written
+// asynchronously after the response; Resync is set when an earlier report
+// from the same agent could not be written, asking it to send its full state
+// again instead of only the changes.
+type AgentReportResponse struct {
+	Success            bool `json:"success"`
+	NextReportInterval int  `json:"nextReportInterval"` // 秒
+	Resync             bool `json:"resync,omitempty"`
+}
@@ -0,0 +1,438 @@
+package api
+
+import "time"
+
+type Server struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	IP            string            `json:"ip"`
+	Status        string            `json:"status"`
+	Lifecycle     string            `json:"lifecycle"`
+	OS            string            `json:"os"`
+	Location      string            `json:"location"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	LastHeartbeat time.Time         `json:"lastHeartbeat"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+// Server statuses, derived from the last heartbeat, metrics thresholds and
+// maintenance.
+const (
+	StatusOnline      = "online"
+	StatusWarning     = "warning"
+	StatusOffline     = "offline"
+	StatusMaintenance = "maintenance"
+)
+
+// Server lifecycle states. Archived servers are hidden from the server list
+// by default and become active again when their agent reports.
+const (
+	LifecycleActive      = "active"
+	LifecycleMaintenance = "maintenance"
+	LifecycleArchived    = "archived"
+)
+
+// ServerSortFields lists the fields the server list can be sorted by.
+var ServerSortFields = []string{
+	"name", "id", "ip", "status", "lifecycle", "os", "location", "last_heartbeat", "created_at",
+	"cpu", "memory", "network",
+}
+
+// BlockedServer is a server ID whose agent reports are rejected, typically
+// because the server was deleted.
+type BlockedServer struct {
+	ServerID  string    `json:"serverId"`
+	Reason    string    `json:"reason,omitempty"`
+	BlockedAt time.Time `json:"blockedAt"`
+}
+
+type Metrics struct {
+	ServerID   string    `json:"serverId"`
+	Timestamp  time.Time `json:"timestamp"`
+	CPU        float64   `json:"cpu"`
+	Memory     float64   `json:"memory"`
+	DiskRead   float64   `json:"diskRead"`
+	DiskWrite  float64   `json:"diskWrite"`
+	NetworkIn  float64   `json:"networkIn"`
+	NetworkOut float64   `json:"networkOut"`
+}
+
+type ServerInfo struct {
+	ServerID    string `json:"serverId"`
+	CPUCores    int    `json:"cpuCores"`
+	TotalMemory int64  `json:"totalMemory"`
+	UsedMemory  int64  `json:"usedMemory"`
+	Uptime      int64  `json:"uptime"`
+}
+
+// Inventory describes the host hardware and operating system.
+type Inventory struct {
+	ServerID             string    `json:"serverId"`
+	ServerName           string    `json:"serverName,omitempty"`
+	Hostname             string    `json:"hostname"`
+	OS                   string    `json:"os"`
+	Platform             string    `json:"platform"`        // 发行版，如 ubuntu
+	PlatformFamily       string    `json:"platformFamily"`  // 如 debian, rhel
+	PlatformVersion      string    `json:"platformVersion"` // 发行版版本
+	KernelVersion        string    `json:"kernelVersion"`
+	KernelArch           string    `json:"kernelArch"`
+	VirtualizationSystem string    `json:"virtualizationSystem"`
+	VirtualizationRole   string    `json:"virtualizationRole"` // guest 或 host
+	BootTime             time.Time `json:"bootTime"`
+	CPUModel             string    `json:"cpuModel"`
+	CPUCores             int       `json:"cpuCores"`
+	TotalMemory          int64     `json:"totalMemory"` // MB
+	TotalDisk            uint64    `json:"totalDisk"`   // MB
+	UpdatedAt            time.Time `json:"updatedAt"`
+}
+
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Arch    string `json:"arch,omitempty"`
+	Source  string `json:"source"` // dpkg, rpm 或 command
+}
+
+// Key identifies a package independently of its version.
+func (p Package) Key() string {
+	return p.Source + "/" + p.Name + "/" + p.Arch
+}
+
+// PackageReport carries either the full package list or the changes since
+// the last acknowledged report.
+type PackageReport struct {
+	Full     bool      `json:"full"`
+	Upserted []Package `json:"upserted,omitempty"` // 新安装或版本变化的软件包
+	Removed  []Package `json:"removed,omitempty"`
+}
+
+// ServerPackage is an installed package located on a specific server.
+type ServerPackage struct {
+	ServerID   string `json:"serverId"`
+	ServerName string `json:"serverName"`
+	Package
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type PackageChange struct {
+	ServerID   string    `json:"serverId"`
+	Name       string    `json:"name"`
+	Arch       string    `json:"arch,omitempty"`
+	Source     string    `json:"source"`
+	Action     string    `json:"action"` // installed, upgraded, downgraded, removed
+	OldVersion string    `json:"oldVersion,omitempty"`
+	NewVersion string    `json:"newVersion,omitempty"`
+	ChangedAt  time.Time `json:"changedAt"`
+}
+
+const (
+	SensorTemperature = "temperature" // °C
+	SensorFan         = "fan"         // RPM
+)
+
+type Sensor struct {
+	Label    string  `json:"label"` // 如 coretemp/Package id 0
+	Kind     string  `json:"kind"`
+	Value    float64 `json:"value"`
+	High     float64 `json:"high,omitempty"`
+	Critical float64 `json:"critical,omitempty"`
+}
+
+type SensorPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// SensorSeries is the history of one sensor.
+type SensorSeries struct {
+	Label  string        `json:"label"`
+	Kind   string        `json:"kind"`
+	Points []SensorPoint `json:"points"`
+}
+
+type Disk struct {
+	Name          string  `json:"name"`
+	MountPoint    string  `json:"mountPoint"`
+	FSType        string  `json:"fsType"`
+	TotalSize     uint64  `json:"totalSize"` // MB
+	UsedSize      uint64  `json:"usedSize"`
+	AvailableSize uint64  `json:"availableSize"`
+	UsagePercent  float64 `json:"usagePercent"`
+}
+
+type Process struct {
+	PID    int32   `json:"pid"`
+	Name   string  `json:"name"`
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+	User   string  `json:"user"`
+	Status string  `json:"status"`
+}
+
+type NetworkInterface struct {
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	UploadSpeed   float64 `json:"uploadSpeed"`   // MB/s
+	DownloadSpeed float64 `json:"downloadSpeed"` // MB/s
+	TotalUpload   uint64  `json:"totalUpload"`   // MB
+	TotalDownload uint64  `json:"totalDownload"` // MB
+	Status        string  `json:"status"`
+}
+
+// AgentStatus describes the agent process itself.
+type AgentStatus struct {
+	Version       string            `json:"version"`
+	StartedAt     time.Time         `json:"startedAt"`
+	RSS           uint64            `json:"rss"` // bytes
+	CPU           float64           `json:"cpu"`
+	Goroutines    int               `json:"goroutines"`
+	QueueDepth    int               `json:"queueDepth"`
+	ReportsSent   uint64            `json:"reportsSent"`
+	ReportsFailed uint64            `json:"reportsFailed"`
+	LastSuccess   *time.Time        `json:"lastSuccess,omitempty"`
+	LastError     string            `json:"lastError,omitempty"`
+	Collectors    []CollectorStatus `json:"collectors"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+type CollectorStatus struct {
+	Name         string    `json:"name"`
+	LastRun      time.Time `json:"lastRun"`
+	LastDuration float64   `json:"lastDuration"` // 毫秒
+	Successes    uint64    `json:"successes"`
+	Failures     uint64    `json:"failures"`
+	LastError    string    `json:"lastError,omitempty"`
+}
+
+// AgentReport is sent by the agent on every tick. Sections are collected on
+// their own intervals and omitted when not due or unchanged.
+type AgentReport struct {
+	ServerID   string             `json:"serverId"`
+	ServerName string             `json:"serverName,omitempty"` // Agent 配置中的服务器名称
+	OS         string             `json:"os,omitempty"`         // 操作系统信息
+	Location   string             `json:"location,omitempty"`   // 服务器位置
+	Labels     map[string]string  `json:"labels"`               // 为 null 时保留服务端已有标签
+	Timestamp  time.Time          `json:"timestamp"`
+	Metrics    *Metrics           `json:"metrics,omitempty"`
+	Info       *ServerInfo        `json:"info,omitempty"`
+	Disks      []Disk             `json:"disks,omitempty"`
+	Processes  []Process          `json:"processes,omitempty"`
+	Network    []NetworkInterface `json:"network,omitempty"`
+	Agent      *AgentStatus       `json:"agent,omitempty"`
+	Inventory  *Inventory         `json:"inventory,omitempty"`
+	Packages   *PackageReport     `json:"packages,omitempty"`
+	Sensors    []Sensor           `json:"sensors,omitempty"`
+}
+
+// Overview aggregates the latest state of the whole fleet.
+type Overview struct {
+	Total         int            `json:"total"`
+	ByStatus      map[string]int `json:"byStatus"`
+	ByOS          map[string]int `json:"byOs"`
+	ByLocation    map[string]int `json:"byLocation"`
+	CPU           Summary        `json:"cpu"`
+	Memory        Summary        `json:"memory"`
+	TopCPU        []ServerValue  `json:"topCpu"`
+	TopMemory     []ServerValue  `json:"topMemory"`
+	TopDisk       []ServerValue  `json:"topDisk"`
+	TopNetwork    []ServerValue  `json:"topNetwork"`
+	DiskThreshold float64        `json:"diskThreshold"`
+	FullDisks     []DiskUsage    `json:"fullDisks"`
+	GeneratedAt   time.Time      `json:"generatedAt"`
+}
+
+// Summary describes the distribution of a metric across servers.
+type Summary struct {
+	Count int     `json:"count"`
+	Avg   float64 `json:"avg"`
+	P95   float64 `json:"p95"`
+	Max   float64 `json:"max"`
+}
+
+type ServerValue struct {
+	ServerID   string  `json:"serverId"`
+	ServerName string  `json:"serverName"`
+	Value      float64 `json:"value"`
+}
+
+type DiskUsage struct {
+	ServerID     string  `json:"serverId"`
+	ServerName   string  `json:"serverName"`
+	MountPoint   string  `json:"mountPoint"`
+	UsagePercent float64 `json:"usagePercent"`
+}
+
+// StorageStats reports how much space the database uses.
+type StorageStats struct {
+	Driver    string       `json:"driver"`
+	SizeBytes int64        `json:"sizeBytes"`           // 数据库总大小
+	FreeBytes int64        `json:"freeBytes,omitempty"` // SQLite 空闲页，可通过 VACUUM 回收
+	Tables    []TableStats `json:"tables"`
+}
+
+type TableStats struct {
+	Name      string `json:"name"`
+	Rows      int64  `json:"rows"`
+	SizeBytes *int64 `json:"sizeBytes,omitempty"` // SQLite 不提供单表大小
+}
+
+// MaintenanceWindow is a planned period during which the selected servers
+// show the maintenance status instead of warning or offline. Start and End
+// bound the first occurrence; recurring windows repeat it daily or weekly
+// until Until.
+type MaintenanceWindow struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Servers    []string   `json:"servers,omitempty"`
+	Selector   string     `json:"selector,omitempty"`
+	Start      time.Time  `json:"start"`
+	End        time.Time  `json:"end"`
+	Recurrence string     `json:"recurrence,omitempty"` // 空、daily 或 weekly
+	Until      *time.Time `json:"until,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Anomaly is a run of consecutive metric samples that deviate from the
+// server's learned baseline for that hour of the week. Value, Expected,
+// StdDev and Score describe the most extreme sample.
+type Anomaly struct {
+	ID        int64     `json:"id"`
+	ServerID  string    `json:"serverId"`
+	Metric    string    `json:"metric"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	Value     float64   `json:"value"`
+	Expected  float64   `json:"expected"`
+	StdDev    float64   `json:"stddev"`
+	Score     float64   `json:"score"` // 偏离的标准差倍数，负数表示低于基线
+	Points    int       `json:"points"`
+}
+
+// MaintenanceWindowStatus is a maintenance window as listed by the API.
+type MaintenanceWindowStatus struct {
+	MaintenanceWindow
+	Active  bool `json:"active"`
+	Expired bool `json:"expired"`
+}
+
+// QueryResult holds one series per server, aligned to Timestamps.
+type QueryResult struct {
+	Metric      string        `json:"metric"`
+	Aggregation string        `json:"aggregation"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	Step        int64         `json:"step"` // 秒
+	Timestamps  []time.Time   `json:"timestamps"`
+	Series      []QuerySeries `json:"series"`
+}
+
+type QuerySeries struct {
+	ServerID   string            `json:"serverId"`
+	ServerName string            `json:"serverName"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Values     []*float64        `json:"values"` // 与 Timestamps 对齐，无数据时为 null
+}
+
+// SLO objective types.
+const (
+	SLOAvailability = "availability"
+	SLOMetric       = "metric"
+)
+
+type SLOObjective struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Target     float64  `json:"target"`
+	Metric     string   `json:"metric,omitempty"`
+	Threshold  float64  `json:"threshold,omitempty"`
+	Comparison string   `json:"comparison,omitempty"`
+	Servers    []string `json:"servers,omitempty"`
+	Selector   string   `json:"selector,omitempty"`
+}
+
+// SLOReport holds the results of every objective for one calendar month.
+type SLOReport struct {
+	Month   string      `json:"month"` // YYYY-MM
+	Start   time.Time   `json:"start"`
+	End     time.Time   `json:"end"` // 当月未结束时为当前时间
+	Results []SLOResult `json:"results"`
+}
+
+// SLOResult is the outcome of one objective for one server over a period.
+type SLOResult struct {
+	Objective  string  `json:"objective"`
+	Type       string  `json:"type"`
+	ServerID   string  `json:"serverId"`
+	ServerName string  `json:"serverName"`
+	Target     float64 `json:"target"`
+	SLI        float64 `json:"sli"` // 实际达标百分比
+	Good       int64   `json:"good"`
+	Total      int64   `json:"total"` // 可用性为时间片数，指标为样本数
+	Met        bool    `json:"met"`
+	// BudgetRemaining is the share of the error budget left, in percent;
+	// negative once the budget is exhausted.
+	BudgetRemaining float64 `json:"budgetRemaining"`
+	// BurnRate is the observed error rate divided by the allowed one; above
+	// 1 the budget runs out before the end of the period.
+	BurnRate float64 `json:"burnRate"`
+	NoData   bool    `json:"noData,omitempty"`
+}
+
+// Report periods.
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+)
+
+// ReportInfo describes one stored HTML report.
+type ReportInfo struct {
+	Name      string    `json:"name"`
+	Period    string    `json:"period"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IngestStats are cumulative counters of the agent report queue.
+type IngestStats struct {
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+	Accepted uint64 `json:"accepted"`
+	Rejected uint64 `json:"rejected"`
+	Written  uint64 `json:"written"`
+	Failed   uint64 `json:"failed"`
+	Batches  uint64 `json:"batches"`
+}
+
+// Backup describes one database backup file.
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RetentionPolicy is the configured retention in days per data kind; zero
+// or absent means data is kept forever.
+type RetentionPolicy struct {
+	Days           map[string]int      `json:"days"`
+	Overrides      []RetentionOverride `json:"overrides"`
+	Vacuum         string              `json:"vacuum"`
+	VacuumInterval string              `json:"vacuumInterval"`
+}
+
+type RetentionOverride struct {
+	Servers  []string       `json:"servers,omitempty"`
+	Selector string         `json:"selector,omitempty"`
+	Days     map[string]int `json:"days"`
+}
+
+// CleanupResult describes one retention cleanup run.
+type CleanupResult struct {
+	StartedAt time.Time        `json:"startedAt"`
+	Duration  string           `json:"duration"`
+	Deleted   map[string]int64 `json:"deleted"` // 按数据类型统计删除的行数
+	Vacuumed  bool             `json:"vacuumed"`
+	Error     string           `json:"error,omitempty"`
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/monitor-system/pkg/api"
+)
+
+// The admin endpoints need Client.AdminKey. They fail with ErrForbidden when
+// the server has no admin key configured, and with ErrNotImplemented for
+// backups when the server does not use SQLite.
+
+func (c *Client) Backups(ctx context.Context) ([]api.Backup, error) {
+	var resp api.BackupsResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/backups", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Backups, nil
+}
+
+func (c *Client) CreateBackup(ctx context.Context) (*api.Backup, error) {
+	var resp api.BackupResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/backups", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Backup, nil
+}
+
+// DownloadBackup returns the contents of a backup file. The caller closes
+// the returned reader.
+func (c *Client) DownloadBackup(ctx context.Context, name string) (io.ReadCloser, error) {
+	return c.download(ctx, "/admin/backups/"+url.PathEscape(name), nil)
+}
+
+// Storage reports the database size, the retention policy and the last
+// cleanup.
+func (c *Client) Storage(ctx context.Context) (*api.StorageResponse, error) {
+	var resp api.StorageResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/storage", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Cleanup applies the retention policy now.
+func (c *Client) Cleanup(ctx context.Context) (*api.CleanupResult, error) {
+	var resp api.CleanupResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/cleanup", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Cleanup, nil
+}
@@ -0,0 +1,220 @@
+// Package client is a Go client for the monitor server REST API. Requests
+// and responses use the types in pkg/api, which the server encodes as well.
+//
+//	c := client.New("http://localhost:8080", apiKey)
+//	list, err := c.ListServers(ctx, client.ListServersOptions{Status: api.StatusOnline})
+//	if errors.Is(err, client.ErrUnauthorized) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/pkg/api"
+)
+
+// Client calls the /api/v1 endpoints with an API key. Its fields may be
+// changed before the first request.
+type Client struct {
+	BaseURL    string // 如 http://localhost:8080
+	APIKey     string
+	HTTPClient *http.Client
+
+	// AdminKey authenticates the /admin endpoints, which do not accept the
+	// API key.
+	AdminKey string
+
+	// MaxRetries is how many times GET requests are retried after a network
+	// error or a 429, 502, 503 or 504 response. Other methods are never
+	// retried, since they are not idempotent.
+	MaxRetries int
+	// RetryWait is the delay before the first retry; it doubles after each
+	// attempt unless the server sends Retry-After.
+	RetryWait time.Duration
+}
+
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 2,
+		RetryWait:  500 * time.Millisecond,
+	}
+}
+
+const maxRetryWait = 30 * time.Second
+
+// send performs a request, retrying where allowed, and returns the response
+// of a 2xx status. The caller closes the body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	u := c.BaseURL + "/api/v1" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	retries := 0
+	if method == http.MethodGet {
+		retries = c.MaxRetries
+	}
+	wait := c.RetryWait
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-API-Key", c.APIKey)
+		if c.AdminKey != "" && strings.HasPrefix(path, "/admin/") {
+			req.Header.Set("X-Admin-Key", c.AdminKey)
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var apiErr *Error
+		if err == nil {
+			apiErr = readError(method, path, resp)
+			if d := retryAfter(resp); d > 0 {
+				wait = d
+			}
+		}
+		if attempt >= retries || (apiErr != nil && !retryable(apiErr.StatusCode)) {
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+func readError(method, path string, resp *http.Response) *Error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	e := &Error{Method: method, Path: path, StatusCode: resp.StatusCode, Body: body}
+	var msg api.Error
+	if json.Unmarshal(body, &msg) == nil && msg.Error != "" {
+		e.Message = msg.Error
+	} else {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	sec, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || sec <= 0 {
+		return 0
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// do sends a request and decodes the JSON response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.New("decode " + path + ": " + err.Error())
+	}
+	return nil
+}
+
+// download returns the body of a file endpoint; the caller closes it.
+func (c *Client) download(ctx context.Context, path string, query url.Values) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func serverPath(id, rest string) string {
+	return "/servers/" + url.PathEscape(id) + rest
+}
+
+// Range selects a time range: Start and End, or the Duration before End
+// (default now). Zero fields use the server defaults. Step aggregates
+// samples into buckets where the endpoint supports it.
+type Range struct {
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Step     time.Duration
+}
+
+func (r Range) values() url.Values {
+	q := url.Values{}
+	if !r.Start.IsZero() {
+		q.Set("start", r.Start.Format(time.RFC3339))
+	}
+	if !r.End.IsZero() {
+		q.Set("end", r.End.Format(time.RFC3339))
+	}
+	if r.Duration > 0 {
+		q.Set("duration", r.Duration.String())
+	}
+	if r.Step > 0 {
+		q.Set("step", strconv.FormatInt(int64(r.Step/time.Second), 10))
+	}
+	return q
+}
+
+// VerifyAuth checks the API key.
+func (c *Client) VerifyAuth(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/auth/verify", nil, nil, nil)
+}
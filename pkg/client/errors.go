@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors matched by *Error through errors.Is, for example
+// errors.Is(err, client.ErrNotFound).
+var (
+	ErrBadRequest     = errors.New("bad request")
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrForbidden      = errors.New("forbidden")
+	ErrNotFound       = errors.New("not found")
+	ErrNotImplemented = errors.New("not implemented")
+	ErrUnavailable    = errors.New("service unavailable")
+)
+
+// Error is returned for every non-2xx response.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string // 响应中的 error 字段，没有时为状态文本
+	Body       []byte // 原始响应，部分接口在出错时也带有结果
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: %s (HTTP %d)", e.Method, e.Path, e.Message, e.StatusCode)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrNotImplemented:
+		return e.StatusCode == http.StatusNotImplemented
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// StatusCode returns the HTTP status of an *Error in err's chain, or 0.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monitor-system/pkg/api"
+)
+
+// OverviewOptions tunes the fleet overview; zero values use the server
+// defaults (top 5, disks at 90% or more).
+type OverviewOptions struct {
+	Top           int
+	DiskThreshold float64
+}
+
+func (c *Client) Overview(ctx context.Context, opts OverviewOptions) (*api.Overview, error) {
+	q := url.Values{}
+	if opts.Top > 0 {
+		q.Set("top", strconv.Itoa(opts.Top))
+	}
+	if opts.DiskThreshold > 0 {
+		q.Set("disk_threshold", strconv.FormatFloat(opts.DiskThreshold, 'f', -1, 64))
+	}
+	var resp api.OverviewResponse
+	if err := c.do(ctx, http.MethodGet, "/overview", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Overview, nil
+}
+
+// Selection picks servers by ID and/or label selector, as in
+// "env=prod,role!=db".
+type Selection struct {
+	Servers  []string
+	Selector string
+}
+
+func (s Selection) set(q url.Values) {
+	if len(s.Servers) > 0 {
+		q.Set("servers", strings.Join(s.Servers, ","))
+	}
+	if s.Selector != "" {
+		q.Set("selector", s.Selector)
+	}
+}
+
+// QueryOptions selects one metric for several servers. Metric defaults to
+// cpu and Aggregation to avg.
+type QueryOptions struct {
+	Selection
+	Range
+	Metric      string // cpu, memory, disk_read, disk_write, network_in, network_out
+	Aggregation string // avg, max, min, p95 或 rate
+}
+
+// Query returns one series per selected server, aligned on the same
+// timestamps.
+func (c *Client) Query(ctx context.Context, opts QueryOptions) (*api.QueryResult, error) {
+	q := opts.Range.values()
+	opts.Selection.set(q)
+	if opts.Metric != "" {
+		q.Set("metric", opts.Metric)
+	}
+	if opts.Aggregation != "" {
+		q.Set("agg", opts.Aggregation)
+	}
+	var resp api.QueryResult
+	if err := c.do(ctx, http.MethodGet, "/query", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ExportOptions selects raw metrics to export; an empty Selection exports
+// every server.
+type ExportOptions struct {
+	Selection
+	Range
+	Format string // csv（默认）或 ndjson
+}
+
+// ExportMetrics streams raw metrics as CSV or NDJSON. The caller closes the
+// returned reader.
+func (c *Client) ExportMetrics(ctx context.Context, opts ExportOptions) (io.ReadCloser, error) {
+	q := opts.Range.values()
+	opts.Selection.set(q)
+	if opts.Format != "" {
+		q.Set("format", opts.Format)
+	}
+	return c.download(ctx, "/export/metrics", q)
+}
+
+// InventoryFilter selects inventory records; empty fields match everything.
+type InventoryFilter struct {
+	Platform        string
+	PlatformFamily  string
+	PlatformVersion string
+	Kernel          string // 内核版本前缀
+	Arch            string
+}
+
+func (c *Client) Inventory(ctx context.Context, f InventoryFilter) ([]api.Inventory, error) {
+	q := url.Values{}
+	for key, value := range map[string]string{
+		"platform": f.Platform, "platformFamily": f.PlatformFamily, "platformVersion": f.PlatformVersion,
+		"kernel": f.Kernel, "arch": f.Arch,
+	} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	var resp api.InventoryResponse
+	if err := c.do(ctx, http.MethodGet, "/inventory", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Inventory, nil
+}
+
+// SearchPackages finds the servers with package name installed, limited to
+// versions matching a constraint such as "<3.0.2" when version is set.
+func (c *Client) SearchPackages(ctx context.Context, name, version string) ([]api.ServerPackage, error) {
+	q := url.Values{"name": {name}}
+	if version != "" {
+		q.Set("version", version)
+	}
+	var resp api.PackageSearchResponse
+	if err := c.do(ctx, http.MethodGet, "/packages", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Packages, nil
+}
+
+func (c *Client) IngestStats(ctx context.Context) (*api.IngestStats, error) {
+	var resp api.IngestStatsResponse
+	if err := c.do(ctx, http.MethodGet, "/ingest/stats", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Ingest, nil
+}
+
+func (c *Client) SLOs(ctx context.Context) ([]api.SLOObjective, error) {
+	var resp api.SLOObjectivesResponse
+	if err := c.do(ctx, http.MethodGet, "/slo", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Objectives, nil
+}
+
+// SLOReport evaluates the objectives for month (YYYY-MM, default the current
+// month) over the selected servers, or all servers.
+func (c *Client) SLOReport(ctx context.Context, month string, sel Selection) (*api.SLOReport, error) {
+	q := url.Values{}
+	sel.set(q)
+	if month != "" {
+		q.Set("month", month)
+	}
+	var resp api.SLOReport
+	if err := c.do(ctx, http.MethodGet, "/slo/report", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) Reports(ctx context.Context) ([]api.ReportInfo, error) {
+	var resp api.ReportsResponse
+	if err := c.do(ctx, http.MethodGet, "/reports", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Reports, nil
+}
+
+// CreateReportOptions selects the report to generate. Period defaults to
+// daily and Date to the last complete period.
+type CreateReportOptions struct {
+	Period string // api.PeriodDaily 或 api.PeriodWeekly
+	Date   time.Time
+	Email  bool
+}
+
+// CreateReport generates (or regenerates) a report. When mailing fails the
+// report still exists; the returned *Error has status 502 and its Body holds
+// an api.ReportResponse.
+func (c *Client) CreateReport(ctx context.Context, opts CreateReportOptions) (*api.ReportInfo, error) {
+	q := url.Values{}
+	if opts.Period != "" {
+		q.Set("period", opts.Period)
+	}
+	if !opts.Date.IsZero() {
+		q.Set("date", opts.Date.Format("2006-01-02"))
+	}
+	if opts.Email {
+		q.Set("email", "true")
+	}
+	var resp api.ReportResponse
+	if err := c.do(ctx, http.MethodPost, "/reports", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Report, nil
+}
+
+// DownloadReport returns the HTML of a stored report. The caller closes the
+// returned reader.
+func (c *Client) DownloadReport(ctx context.Context, name string) (io.ReadCloser, error) {
+	return c.download(ctx, "/reports/"+url.PathEscape(name), nil)
+}
+
+// SendReport mails a stored report.
+func (c *Client) SendReport(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/reports/"+url.PathEscape(name)+"/send", nil, nil, nil)
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/monitor-system/pkg/api"
+)
+
+// History returns the metrics of a server in r, averaged into buckets when
+// r.Step is set or the range holds too many samples.
+func (c *Client) History(ctx context.Context, id string, r Range) (*api.HistoryResponse, error) {
+	var resp api.HistoryResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/history"), r.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Processes returns the top processes sorted by "cpu" or "memory"; limit 0
+// uses the server default.
+func (c *Client) Processes(ctx context.Context, id, sortBy string, limit int) ([]api.Process, error) {
+	q := url.Values{}
+	if sortBy != "" {
+		q.Set("sortBy", sortBy)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var resp api.ProcessesResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/processes"), q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Processes, nil
+}
+
+func (c *Client) Disks(ctx context.Context, id string) ([]api.Disk, error) {
+	var resp api.DisksResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/disks"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Disks, nil
+}
+
+func (c *Client) NetworkInterfaces(ctx context.Context, id string) ([]api.NetworkInterface, error) {
+	var resp api.NetworkResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/network"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Interfaces, nil
+}
+
+// AgentStatus returns the state of the server's agent process.
+func (c *Client) AgentStatus(ctx context.Context, id string) (*api.AgentStatus, error) {
+	var resp api.AgentStatusResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/agent"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Agent, nil
+}
+
+// Sensors returns the latest sensor readings and their history in r.
+func (c *Client) Sensors(ctx context.Context, id string, r Range) (*api.SensorsResponse, error) {
+	var resp api.SensorsResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/sensors"), r.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) Packages(ctx context.Context, id string) ([]api.Package, error) {
+	var resp api.PackagesResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/packages"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Packages, nil
+}
+
+// PackageHistory returns the package installs, upgrades and removals in r.
+func (c *Client) PackageHistory(ctx context.Context, id string, r Range) (*api.PackageHistoryResponse, error) {
+	var resp api.PackageHistoryResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/packages/history"), r.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Anomalies returns the anomalies detected in r, only for metric when it is
+// not empty.
+func (c *Client) Anomalies(ctx context.Context, id, metric string, r Range) (*api.AnomaliesResponse, error) {
+	q := r.values()
+	if metric != "" {
+		q.Set("metric", metric)
+	}
+	var resp api.AnomaliesResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, "/anomalies"), q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
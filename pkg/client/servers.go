@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/monitor-system/pkg/api"
+)
+
+// ListServersOptions filters, sorts and pages the server list. Zero values
+// use the server defaults.
+type ListServersOptions struct {
+	Search    string
+	Status    string
+	Lifecycle string // 默认不含已归档服务器，"all" 表示全部
+	Sort      string // api.ServerSortFields 之一
+	Desc      bool
+	Limit     int
+	Offset    int
+}
+
+func (c *Client) ListServers(ctx context.Context, opts ListServersOptions) (*api.ServerListResponse, error) {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("search", opts.Search)
+	set("status", opts.Status)
+	set("lifecycle", opts.Lifecycle)
+	set("sort", opts.Sort)
+	if opts.Desc {
+		q.Set("order", "desc")
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var resp api.ServerListResponse
+	if err := c.do(ctx, http.MethodGet, "/servers", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) GetServer(ctx context.Context, id string) (*api.ServerDetail, error) {
+	var resp api.ServerDetailResponse
+	if err := c.do(ctx, http.MethodGet, serverPath(id, ""), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Server, nil
+}
+
+// DeleteServer removes a server and its data. With block set the ID is also
+// block-listed so its agent cannot register it again.
+func (c *Client) DeleteServer(ctx context.Context, id string, block bool) error {
+	q := url.Values{"block": {strconv.FormatBool(block)}}
+	return c.do(ctx, http.MethodDelete, serverPath(id, ""), q, nil, nil)
+}
+
+func (c *Client) setLifecycle(ctx context.Context, id, action string) (*api.Server, error) {
+	var resp api.ServerResponse
+	if err := c.do(ctx, http.MethodPost, serverPath(id, "/"+action), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Server, nil
+}
+
+// ArchiveServer hides a server from the server list until its agent reports
+// again.
+func (c *Client) ArchiveServer(ctx context.Context, id string) (*api.Server, error) {
+	return c.setLifecycle(ctx, id, "archive")
+}
+
+// StartMaintenance puts a server into maintenance until RestoreServer.
+func (c *Client) StartMaintenance(ctx context.Context, id string) (*api.Server, error) {
+	return c.setLifecycle(ctx, id, "maintenance")
+}
+
+// RestoreServer returns an archived or maintenance server to active.
+func (c *Client) RestoreServer(ctx context.Context, id string) (*api.Server, error) {
+	return c.setLifecycle(ctx, id, "restore")
+}
+
+func (c *Client) BlockedServers(ctx context.Context) ([]api.BlockedServer, error) {
+	var resp api.BlockedServersResponse
+	if err := c.do(ctx, http.MethodGet, "/blocked", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Blocked, nil
+}
+
+// BlockServer rejects further reports from the agent with this server ID.
+func (c *Client) BlockServer(ctx context.Context, id, reason string) error {
+	req := api.BlockServerRequest{ServerID: id, Reason: reason}
+	return c.do(ctx, http.MethodPost, "/blocked", nil, req, nil)
+}
+
+func (c *Client) UnblockServer(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/blocked/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// MaintenanceWindows lists maintenance windows, only those applying to
+// serverID when it is not empty.
+func (c *Client) MaintenanceWindows(ctx context.Context, serverID string) ([]api.MaintenanceWindowStatus, error) {
+	q := url.Values{}
+	if serverID != "" {
+		q.Set("server", serverID)
+	}
+	var resp api.MaintenanceWindowsResponse
+	if err := c.do(ctx, http.MethodGet, "/maintenance", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Windows, nil
+}
+
+func (c *Client) CreateMaintenanceWindow(ctx context.Context, w api.MaintenanceWindow) (*api.MaintenanceWindow, error) {
+	var resp api.MaintenanceWindowResponse
+	if err := c.do(ctx, http.MethodPost, "/maintenance", nil, w, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Window, nil
+}
+
+func (c *Client) DeleteMaintenanceWindow(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/maintenance/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}